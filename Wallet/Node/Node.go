@@ -0,0 +1,78 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+// Package Node is how the wallet talks to factomd.  Everything the wallet
+// asks of the network goes through a Client, so the wallet can be pointed
+// at a real factomd over HTTP, or at the in-memory Mock for testing.
+package Node
+
+import (
+	"context"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+)
+
+// A Rejection is factomd refusing a request it got, as opposed to the
+// request not getting there.  Sending it to another node won't help.
+type Rejection string
+
+func (r Rejection) Error() string {
+	return string(r)
+}
+
+// IsRejection is true if the error is a reply from factomd, rather than a
+// failure to reach it.
+func IsRejection(err error) bool {
+	switch err.(type) {
+	case Rejection, *RPCError:
+		return true
+	}
+	return false
+}
+
+// Unreachable is a request that never got to factomd, because there was no
+// connection to send it over.  factomd can't have acted on it.
+type Unreachable string
+
+func (u Unreachable) Error() string {
+	return string(u)
+}
+
+// IsUnreachable is true if the error is a failure to connect to factomd,
+// before any of the request was sent.
+func IsUnreachable(err error) bool {
+	_, ok := err.(Unreachable)
+	return ok
+}
+
+// A Client is a connection to a factomd node.  Addresses are given as the
+// hex of the address hash, the way LookupAddress returns them.  Every call
+// gives up when its context is cancelled.
+type Client interface {
+	// Balances, in factoshis for a Factoid address and entry credits for
+	// an entry credit address.
+	FactoidBalance(ctx context.Context, address string) (int64, error)
+	ECBalance(ctx context.Context, address string) (int64, error)
+
+	// GetFee returns the number of factoshis an entry credit costs, which
+	// is also the rate transaction fees are worked out with.
+	GetFee(ctx context.Context) (int64, error)
+	GetProperties(ctx context.Context) (protocol string, factomd string, err error)
+
+	// FactoidSubmit sends a signed, marshalled transaction.
+	FactoidSubmit(ctx context.Context, trans []byte) error
+	// CommitChain and CommitEntry send a commit signed with an entry
+	// credit key.
+	CommitChain(ctx context.Context, msg []byte) error
+	CommitEntry(ctx context.Context, msg []byte) error
+	// RevealEntry sends the marshalled entry of a commit.
+	RevealEntry(ctx context.Context, entry []byte) error
+
+	// The directory block chain, and the Factoid and entry credit blocks
+	// it holds, by KeyMR, or header hash for an entry credit block.
+	DirectoryBlockHead(ctx context.Context) (string, error)
+	DirectoryBlock(ctx context.Context, keymr string) (interfaces.IDirectoryBlock, error)
+	FactoidBlock(ctx context.Context, keymr string) (interfaces.IFBlock, error)
+	EntryCreditBlock(ctx context.Context, keymr string) (interfaces.IEntryCreditBlock, error)
+}
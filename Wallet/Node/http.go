@@ -0,0 +1,203 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package Node
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/FactomProject/factomd/common/directoryBlock"
+	"github.com/FactomProject/factomd/common/entryCreditBlock"
+	"github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/common/interfaces"
+)
+
+// An HTTPClient talks to factomd over its v1 REST API.
+type HTTPClient struct {
+	Address string
+	Port    int
+	Timeout time.Duration
+}
+
+var _ Client = (*HTTPClient)(nil)
+
+func NewHTTPClient(address string, port int) *HTTPClient {
+	return &HTTPClient{Address: address, Port: port, Timeout: DefaultTimeout}
+}
+
+// The reply factomd gives to most v1 requests.
+type response struct {
+	Response string
+	Success  bool
+}
+
+func (c *HTTPClient) url(path string) string {
+	return fmt.Sprintf("http://%s:%d/v1/%s", c.Address, c.Port, path)
+}
+
+// get unmarshals the reply to a GET into v.  factomd turning the request
+// down with a 4xx status is a Rejection.
+func (c *HTTPClient) get(ctx context.Context, path string, v interface{}) error {
+	body, status, err := send(ctx, c.Timeout, "GET", c.url(path), nil)
+	if err != nil {
+		return err
+	}
+	if status >= 400 && status < 500 {
+		return Rejection(string(body))
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("%s", body)
+	}
+	return json.Unmarshal(body, v)
+}
+
+func (c *HTTPClient) post(ctx context.Context, path string, in interface{}) ([]byte, error) {
+	j, err := json.Marshal(in)
+	if err != nil {
+		return nil, fmt.Errorf("Could not create json post: %v", err)
+	}
+	body, _, err := send(ctx, c.Timeout, "POST", c.url(path), j)
+	if err != nil {
+		return nil, postError(err)
+	}
+	return body, nil
+}
+
+func (c *HTTPClient) balance(ctx context.Context, path string) (int64, error) {
+	b := new(response)
+	if err := c.get(ctx, path, b); err != nil {
+		return 0, err
+	}
+	if !b.Success {
+		return 0, Rejection(b.Response)
+	}
+	return strconv.ParseInt(b.Response, 10, 64)
+}
+
+func (c *HTTPClient) FactoidBalance(ctx context.Context, address string) (int64, error) {
+	return c.balance(ctx, "factoid-balance/"+address)
+}
+
+func (c *HTTPClient) ECBalance(ctx context.Context, address string) (int64, error) {
+	return c.balance(ctx, "entry-credit-balance/"+address)
+}
+
+func (c *HTTPClient) GetFee(ctx context.Context) (int64, error) {
+	b := new(struct {
+		Response struct {
+			Fee int64
+		}
+		Success bool
+	})
+	if err := c.get(ctx, "factoid-get-fee/", b); err != nil {
+		return 0, err
+	}
+	return b.Response.Fee, nil
+}
+
+func (c *HTTPClient) GetProperties(ctx context.Context) (string, string, error) {
+	b := new(struct {
+		Protocol_Version string
+		Factomd_Version  string
+	})
+	if err := c.get(ctx, "properties/", b); err != nil {
+		return "", "", err
+	}
+	return b.Protocol_Version, b.Factomd_Version, nil
+}
+
+// submit posts to factomd and returns a Rejection if it answers that it
+// didn't take what was posted.
+func (c *HTTPClient) submit(ctx context.Context, path string, in interface{}) error {
+	body, err := c.post(ctx, path, in)
+	if err != nil {
+		return err
+	}
+	r := new(response)
+	if err := json.Unmarshal(body, r); err != nil {
+		return err
+	}
+	if !r.Success {
+		return Rejection(r.Response)
+	}
+	return nil
+}
+
+func (c *HTTPClient) FactoidSubmit(ctx context.Context, trans []byte) error {
+	s := struct{ Transaction string }{hex.EncodeToString(trans)}
+	return c.submit(ctx, "factoid-submit/", s)
+}
+
+func (c *HTTPClient) CommitChain(ctx context.Context, msg []byte) error {
+	s := struct{ CommitChainMsg string }{hex.EncodeToString(msg)}
+	return c.submit(ctx, "commit-chain", s)
+}
+
+func (c *HTTPClient) CommitEntry(ctx context.Context, msg []byte) error {
+	s := struct{ CommitEntryMsg string }{hex.EncodeToString(msg)}
+	return c.submit(ctx, "commit-entry/", s)
+}
+
+func (c *HTTPClient) RevealEntry(ctx context.Context, entry []byte) error {
+	s := struct{ Entry string }{hex.EncodeToString(entry)}
+	return c.submit(ctx, "reveal-entry/", s)
+}
+
+func (c *HTTPClient) DirectoryBlockHead(ctx context.Context) (string, error) {
+	b := new(struct{ KeyMR string })
+	if err := c.get(ctx, "directory-block-head/", b); err != nil {
+		return "", err
+	}
+	return b.KeyMR, nil
+}
+
+func (c *HTTPClient) getRaw(ctx context.Context, keymr string) ([]byte, error) {
+	b := new(struct{ Data string })
+	if err := c.get(ctx, "get-raw-data/"+keymr, b); err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(b.Data)
+}
+
+func (c *HTTPClient) DirectoryBlock(ctx context.Context, keymr string) (interfaces.IDirectoryBlock, error) {
+	data, err := c.getRaw(ctx, keymr)
+	if err != nil {
+		return nil, err
+	}
+	db := new(directoryBlock.DirectoryBlock)
+	if err := db.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func (c *HTTPClient) FactoidBlock(ctx context.Context, keymr string) (interfaces.IFBlock, error) {
+	data, err := c.getRaw(ctx, keymr)
+	if err != nil {
+		return nil, err
+	}
+	fb := new(factoid.FBlock)
+	if err := fb.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return fb, nil
+}
+
+func (c *HTTPClient) EntryCreditBlock(ctx context.Context, keymr string) (interfaces.IEntryCreditBlock, error) {
+	data, err := c.getRaw(ctx, keymr)
+	if err != nil {
+		return nil, err
+	}
+	ecb := entryCreditBlock.NewECBlock()
+	if err := ecb.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return ecb, nil
+}
@@ -0,0 +1,47 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package Node_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/FactomProject/fctwallet2/Wallet/Node"
+)
+
+func TestHTTPClientCommits(t *testing.T) {
+	ctx := context.Background()
+	replies := map[string]string{
+		"/v1/commit-chain":  `{"Response": "Chain already exists", "Success": false}`,
+		"/v1/commit-entry/": `{"Response": "Entry Commit Success", "Success": true}`,
+	}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reply, ok := replies[r.URL.Path]
+		if !ok {
+			t.Errorf("Request sent to %s", r.URL.Path)
+		}
+		w.Write([]byte(reply))
+	}))
+	defer s.Close()
+	host, port, err := net.SplitHostPort(strings.TrimPrefix(s.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, _ := strconv.Atoi(port)
+	c := Node.NewHTTPClient(host, p)
+
+	err = c.CommitChain(ctx, []byte{1, 2, 3})
+	if !Node.IsRejection(err) || err.Error() != "Chain already exists" {
+		t.Errorf("Expected the commit to be rejected, got %v", err)
+	}
+	if err := c.CommitEntry(ctx, []byte{1, 2, 3}); err != nil {
+		t.Error(err)
+	}
+}
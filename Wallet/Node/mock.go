@@ -0,0 +1,463 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package Node
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/FactomProject/ed25519"
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/directoryBlock"
+	"github.com/FactomProject/factomd/common/entryBlock"
+	"github.com/FactomProject/factomd/common/entryCreditBlock"
+	"github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// A Mock is an in-memory factomd for testing.  It keeps a ledger of
+// Factoid and entry credit balances, checks and applies the transactions
+// and commits it is sent, and puts them into new Factoid and entry credit
+// blocks each time ProduceBlock is called.  Like factomd, balances change as soon as a
+// transaction is accepted, not when it is put in a block.
+type Mock struct {
+	mutex sync.Mutex
+
+	Protocol string
+	Factomd  string
+
+	rate uint64
+	fct  map[string]int64
+	ec   map[string]int64
+
+	// Waiting for the next block.  Funds are paid out by the coinbase.
+	pending   []interfaces.ITransaction
+	funds     []output
+	ecPending []interfaces.IECBlockEntry
+	commits   int
+	down      bool
+
+	// Hashes of the entries committed and not yet revealed, and the count
+	// of those revealed.
+	committed map[string]bool
+	reveals   int
+
+	// KeyMRs of the directory blocks, oldest first.
+	chain    []string
+	dblocks  map[string]interfaces.IDirectoryBlock
+	fblocks  map[string]interfaces.IFBlock
+	ecblocks map[string]interfaces.IEntryCreditBlock
+}
+
+type output struct {
+	address []byte
+	amount  uint64
+}
+
+var _ Client = (*Mock)(nil)
+
+// Sizes of a signed entry commit and chain commit.
+const (
+	commitEntrySize = 136
+	commitChainSize = 200
+)
+
+// NewMock returns a node with empty balances and no blocks, where an entry
+// credit costs rate factoshis.
+func NewMock(rate uint64) *Mock {
+	m := new(Mock)
+	m.Protocol = "0.1.5"
+	m.Factomd = "mock"
+	m.rate = rate
+	m.fct = make(map[string]int64)
+	m.ec = make(map[string]int64)
+	m.committed = make(map[string]bool)
+	m.dblocks = make(map[string]interfaces.IDirectoryBlock)
+	m.fblocks = make(map[string]interfaces.IFBlock)
+	m.ecblocks = make(map[string]interfaces.IEntryCreditBlock)
+	return m
+}
+
+// ErrDown is returned by every call to a mock that is down.
+var ErrDown error = Unreachable("The node is down")
+
+// SetDown makes the node unreachable, or reachable again.
+func (m *Mock) SetDown(down bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.down = down
+}
+
+// check returns the error for a call the mock won't answer.  The mock must
+// be locked.
+func (m *Mock) check(ctx context.Context) error {
+	if m.down {
+		return ErrDown
+	}
+	return ctx.Err()
+}
+
+// SetRate changes the number of factoshis an entry credit costs.
+func (m *Mock) SetRate(rate uint64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.rate = rate
+}
+
+// Fund pays factoshis to the Factoid address, given as the hex of the
+// address hash.  The payment shows up in the coinbase of the next block.
+func (m *Mock) Fund(address string, amount uint64) error {
+	adr, err := hex.DecodeString(address)
+	if err != nil {
+		return err
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.fct[address] += int64(amount)
+	m.funds = append(m.funds, output{adr, amount})
+	return nil
+}
+
+// FundEC gives entry credits to the entry credit address, given as the hex
+// of its public key.
+func (m *Mock) FundEC(address string, credits int64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.ec[address] += credits
+}
+
+// Pending returns the transactions accepted since the last block.
+func (m *Mock) Pending() []interfaces.ITransaction {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return append([]interfaces.ITransaction(nil), m.pending...)
+}
+
+// Commits returns the number of commits accepted.
+func (m *Mock) Commits() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.commits
+}
+
+// Reveals returns the number of entries revealed.
+func (m *Mock) Reveals() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.reveals
+}
+
+// Height returns the height of the highest block, or -1 if no blocks have
+// been produced.
+func (m *Mock) Height() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return len(m.chain) - 1
+}
+
+// ProduceBlock puts the funds and transactions waiting into a new Factoid
+// block, the commits waiting into a new entry credit block, and both into a
+// new directory block.  Returns the KeyMR of the directory block.
+func (m *Mock) ProduceBlock() (string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	height := uint32(len(m.chain))
+	fb := factoid.NewFBlock(m.rate, height)
+	coinbase := new(factoid.Transaction)
+	coinbase.SetMilliTimestamp(uint64(time.Now().UnixNano() / 1000000))
+	for _, f := range m.funds {
+		coinbase.AddOutput(factoid.NewAddress(f.address), f.amount)
+	}
+	if err := fb.AddCoinbase(coinbase); err != nil {
+		return "", err
+	}
+	for _, t := range m.pending {
+		if err := fb.AddTransaction(t); err != nil {
+			return "", err
+		}
+	}
+	fkey := fb.GetKeyMR()
+
+	ecb := entryCreditBlock.NewECBlock()
+	ecb.GetHeader().SetDBHeight(height)
+	for _, e := range m.ecPending {
+		ecb.GetBody().AddEntry(e)
+	}
+	eckey, err := ecb.HeaderHash()
+	if err != nil {
+		return "", err
+	}
+
+	db := new(directoryBlock.DirectoryBlock)
+	header := new(directoryBlock.DBlockHeader)
+	header.SetDBHeight(height)
+	if height == 0 {
+		header.SetPrevKeyMR(primitives.NewZeroHash())
+	} else {
+		prev, err := primitives.HexToHash(m.chain[height-1])
+		if err != nil {
+			return "", err
+		}
+		header.SetPrevKeyMR(prev)
+	}
+	db.Header = header
+	fEntry := new(directoryBlock.DBEntry)
+	fEntry.ChainID = primitives.NewHash(constants.FACTOID_CHAINID)
+	fEntry.KeyMR = fkey
+	ecEntry := new(directoryBlock.DBEntry)
+	ecEntry.ChainID = primitives.NewHash(constants.EC_CHAINID)
+	ecEntry.KeyMR = eckey
+	db.SetDBEntries([]interfaces.IDBEntry{fEntry, ecEntry})
+	dkey := db.GetKeyMR().String()
+
+	m.fblocks[fkey.String()] = fb
+	m.ecblocks[eckey.String()] = ecb
+	m.dblocks[dkey] = db
+	m.chain = append(m.chain, dkey)
+	m.pending = nil
+	m.funds = nil
+	m.ecPending = nil
+	return dkey, nil
+}
+
+// Fork drops the blocks above the height from the chain, so the blocks
+// produced next form a fork from there.  A height of -1 drops every block.
+// The dropped blocks can still be read by KeyMR, and the balances are left
+// as they are.  Fork blocks only differ from the ones dropped if their
+// funds, transactions or commits do.
+func (m *Mock) Fork(height int) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if height < -1 || height >= len(m.chain) {
+		return fmt.Errorf("No block at height %d to fork from", height)
+	}
+	m.chain = m.chain[:height+1]
+	return nil
+}
+
+func (m *Mock) FactoidBalance(ctx context.Context, address string) (int64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if err := m.check(ctx); err != nil {
+		return 0, err
+	}
+	return m.fct[address], nil
+}
+
+func (m *Mock) ECBalance(ctx context.Context, address string) (int64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if err := m.check(ctx); err != nil {
+		return 0, err
+	}
+	return m.ec[address], nil
+}
+
+func (m *Mock) GetFee(ctx context.Context) (int64, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if err := m.check(ctx); err != nil {
+		return 0, err
+	}
+	return int64(m.rate), nil
+}
+
+func (m *Mock) GetProperties(ctx context.Context) (string, string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if err := m.check(ctx); err != nil {
+		return "", "", err
+	}
+	return m.Protocol, m.Factomd, nil
+}
+
+// FactoidSubmit accepts a transaction if it is signed, pays at least the
+// fee, and its inputs have the balance to cover it.
+func (m *Mock) FactoidSubmit(ctx context.Context, data []byte) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if err := m.check(ctx); err != nil {
+		return err
+	}
+
+	t := new(factoid.Transaction)
+	if err := t.UnmarshalBinary(data); err != nil {
+		return Rejection(err.Error())
+	}
+	if err := t.ValidateSignatures(); err != nil {
+		return Rejection(err.Error())
+	}
+
+	fee, err := t.CalculateFee(m.rate)
+	if err != nil {
+		return err
+	}
+	ins, err := t.TotalInputs()
+	if err != nil {
+		return err
+	}
+	outs, err := t.TotalOutputs()
+	if err != nil {
+		return err
+	}
+	ecs, err := t.TotalECs()
+	if err != nil {
+		return err
+	}
+	if ins < outs+ecs+fee {
+		return Rejection(fmt.Sprintf("Insufficient fee - %d vs %d", int64(ins)-int64(outs)-int64(ecs), fee))
+	}
+
+	spent := make(map[string]int64)
+	for _, in := range t.GetInputs() {
+		spent[hex.EncodeToString(in.GetAddress().Bytes())] += int64(in.GetAmount())
+	}
+	for adr, amount := range spent {
+		if m.fct[adr] < amount {
+			return Rejection("Insufficient balance in " + adr)
+		}
+	}
+
+	for adr, amount := range spent {
+		m.fct[adr] -= amount
+	}
+	for _, out := range t.GetOutputs() {
+		m.fct[hex.EncodeToString(out.GetAddress().Bytes())] += int64(out.GetAmount())
+	}
+	for _, ec := range t.GetECOutputs() {
+		m.ec[hex.EncodeToString(ec.GetAddress().Bytes())] += int64(ec.GetAmount() / m.rate)
+	}
+	m.pending = append(m.pending, t)
+	return nil
+}
+
+func (m *Mock) CommitChain(ctx context.Context, msg []byte) error {
+	return m.commit(ctx, msg, commitChainSize, entryCreditBlock.NewCommitChain())
+}
+
+func (m *Mock) CommitEntry(ctx context.Context, msg []byte) error {
+	return m.commit(ctx, msg, commitEntrySize, entryCreditBlock.NewCommitEntry())
+}
+
+// A signed commit is the commit, ending with the number of entry credits
+// it pays, then the entry credit public key and the signature.  Accepted
+// commits are read into the entry, for the next entry credit block.
+func (m *Mock) commit(ctx context.Context, msg []byte, size int, entry interfaces.IECBlockEntry) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if err := m.check(ctx); err != nil {
+		return err
+	}
+
+	if len(msg) != size {
+		return Rejection(fmt.Sprintf("Invalid commit of %d bytes", len(msg)))
+	}
+	data := msg[:size-96]
+	pub := new([ed25519.PublicKeySize]byte)
+	copy(pub[:], msg[size-96:size-64])
+	sig := new([ed25519.SignatureSize]byte)
+	copy(sig[:], msg[size-64:])
+	if !ed25519.Verify(pub, data, sig) {
+		return Rejection("Invalid commit signature")
+	}
+
+	if err := entry.UnmarshalBinary(msg); err != nil {
+		return Rejection(err.Error())
+	}
+
+	adr := hex.EncodeToString(pub[:])
+	credits := int64(data[len(data)-1])
+	if m.ec[adr] < credits {
+		return Rejection("Insufficient entry credits in " + adr)
+	}
+	m.ec[adr] -= credits
+	m.commits++
+	m.ecPending = append(m.ecPending, entry)
+	switch c := entry.(type) {
+	case *entryCreditBlock.CommitEntry:
+		m.committed[c.EntryHash.String()] = true
+	case *entryCreditBlock.CommitChain:
+		m.committed[c.EntryHash.String()] = true
+	}
+	return nil
+}
+
+// RevealEntry accepts an entry that has been committed.  The entry isn't
+// kept; the mock has no entry blocks.
+func (m *Mock) RevealEntry(ctx context.Context, data []byte) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if err := m.check(ctx); err != nil {
+		return err
+	}
+
+	e := entryBlock.NewEntry()
+	if err := e.UnmarshalBinary(data); err != nil {
+		return Rejection(err.Error())
+	}
+	hash := e.GetHash().String()
+	if !m.committed[hash] {
+		return Rejection("Entry " + hash + " has not been committed")
+	}
+	delete(m.committed, hash)
+	m.reveals++
+	return nil
+}
+
+func (m *Mock) DirectoryBlockHead(ctx context.Context) (string, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if err := m.check(ctx); err != nil {
+		return "", err
+	}
+	if len(m.chain) == 0 {
+		return "", fmt.Errorf("No directory blocks")
+	}
+	return m.chain[len(m.chain)-1], nil
+}
+
+func (m *Mock) DirectoryBlock(ctx context.Context, keymr string) (interfaces.IDirectoryBlock, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if err := m.check(ctx); err != nil {
+		return nil, err
+	}
+	db, ok := m.dblocks[keymr]
+	if !ok {
+		return nil, fmt.Errorf("Directory block %s not found", keymr)
+	}
+	return db, nil
+}
+
+func (m *Mock) FactoidBlock(ctx context.Context, keymr string) (interfaces.IFBlock, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if err := m.check(ctx); err != nil {
+		return nil, err
+	}
+	fb, ok := m.fblocks[keymr]
+	if !ok {
+		return nil, fmt.Errorf("Factoid block %s not found", keymr)
+	}
+	return fb, nil
+}
+
+func (m *Mock) EntryCreditBlock(ctx context.Context, keymr string) (interfaces.IEntryCreditBlock, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if err := m.check(ctx); err != nil {
+		return nil, err
+	}
+	ecb, ok := m.ecblocks[keymr]
+	if !ok {
+		return nil, fmt.Errorf("Entry credit block %s not found", keymr)
+	}
+	return ecb, nil
+}
@@ -0,0 +1,136 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package Node_test
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"github.com/FactomProject/ed25519"
+	"github.com/FactomProject/factomd/common/entryBlock"
+	"github.com/FactomProject/factomd/common/entryCreditBlock"
+	"github.com/FactomProject/fctwallet2/Wallet/Node"
+)
+
+func TestMockProducesBlocks(t *testing.T) {
+	ctx := context.Background()
+	m := Node.NewMock(1000)
+	adr := "dceb1ce5778444e7777172e1f586488d2382fb1037887cd79a70b0cba4fb3dce"
+
+	if _, err := m.DirectoryBlockHead(ctx); err == nil {
+		t.Error("A mock with no blocks has a head")
+	}
+	if err := m.Fund(adr, 500000000); err != nil {
+		t.Fatal(err)
+	}
+	if bal, _ := m.FactoidBalance(ctx, adr); bal != 500000000 {
+		t.Errorf("Balance is %d, expected 500000000", bal)
+	}
+
+	first, err := m.ProduceBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := m.ProduceBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Height() != 1 {
+		t.Errorf("Height is %d, expected 1", m.Height())
+	}
+	head, err := m.DirectoryBlockHead(ctx)
+	if err != nil || head != second {
+		t.Fatalf("Head is %s, expected %s: %v", head, second, err)
+	}
+
+	db, err := m.DirectoryBlock(ctx, head)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prev := hex.EncodeToString(db.GetHeader().GetPrevKeyMR().Bytes()); prev != first {
+		t.Errorf("Previous block is %s, expected %s", prev, first)
+	}
+
+	db, err = m.DirectoryBlock(ctx, first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fb, err := m.FactoidBlock(ctx, hex.EncodeToString(db.GetDBEntries()[0].GetKeyMR().Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	coinbase := fb.GetTransactions()[0]
+	if len(coinbase.GetOutputs()) != 1 || coinbase.GetOutputs()[0].GetAmount() != 500000000 {
+		t.Error("The funds are not paid out in the coinbase")
+	}
+}
+
+func TestMockCommits(t *testing.T) {
+	ctx := context.Background()
+	m := Node.NewMock(1000)
+	pub, pri, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry, err := entryBlock.NewEntry().MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.RevealEntry(ctx, entry); err == nil {
+		t.Error("Revealed an entry that was not committed")
+	}
+
+	// Version, timestamp, entry hash, and the number of entry credits.
+	msg := make([]byte, 40)
+	copy(msg[7:39], entryBlock.NewEntry().GetHash().Bytes())
+	msg[39] = 1
+	sig := ed25519.Sign(pri, msg)
+	commit := append(append(msg, pub[:]...), sig[:]...)
+
+	if err := m.CommitEntry(ctx, commit); err == nil {
+		t.Error("Committed without entry credits")
+	}
+	m.FundEC(hex.EncodeToString(pub[:]), 5)
+	if err := m.CommitEntry(ctx, commit); err != nil {
+		t.Fatal(err)
+	}
+	if bal, _ := m.ECBalance(ctx, hex.EncodeToString(pub[:])); bal != 4 {
+		t.Errorf("Entry credit balance is %d, expected 4", bal)
+	}
+
+	commit[0] = 1
+	if err := m.CommitEntry(ctx, commit); err == nil {
+		t.Error("Accepted a commit with a bad signature")
+	}
+	if err := m.CommitChain(ctx, commit); err == nil {
+		t.Error("Accepted an entry commit as a chain commit")
+	}
+	if m.Commits() != 1 {
+		t.Errorf("%d commits accepted, expected 1", m.Commits())
+	}
+	if err := m.RevealEntry(ctx, entry); err != nil || m.Reveals() != 1 {
+		t.Errorf("The committed entry was not revealed: %v", err)
+	}
+
+	// The commit goes in the entry credit block of the next block.
+	keymr, err := m.ProduceBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := m.DirectoryBlock(ctx, keymr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecb, err := m.EntryCreditBlock(ctx, hex.EncodeToString(db.GetDBEntries()[1].GetKeyMR().Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entries := ecb.GetBody().GetEntries(); len(entries) != 1 || entries[0].ECID() != entryCreditBlock.ECIDEntryCommit {
+		t.Error("The commit is not in the entry credit block")
+	}
+}
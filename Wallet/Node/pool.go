@@ -0,0 +1,341 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package Node
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+)
+
+// A Pool spreads the wallet over several factomd nodes.  Each node is
+// health checked by reading the head of its directory block chain, which
+// gives its height and how fast it answers.  Reads go to the healthiest
+// node: the highest, then the quickest.  A node that can't be reached is
+// skipped until it passes a health check.
+//
+// Reads that fail to reach a node are tried again, up to ReadAttempts
+// times, on the healthiest node left.  Submissions and commits are never
+// sent to the same node twice.  If a node can't be connected to, they are
+// sent on to the next node, since nothing was sent to the first.  Any other
+// failure, such as a timeout waiting for the reply, is returned, because
+// the first node may have taken the submission and passed it on.
+type Pool struct {
+	mutex     sync.RWMutex
+	endpoints []*Endpoint
+	current   *Endpoint
+}
+
+var _ Client = (*Pool)(nil)
+
+// An Endpoint is a node in the pool, and how it did on its last health
+// check.
+type Endpoint struct {
+	Name    string
+	Client  Client
+	Height  uint32
+	Latency time.Duration
+	Checked time.Time
+	Err     error
+}
+
+func (e *Endpoint) Healthy() bool {
+	return e.Err == nil
+}
+
+// How many times a read is tried, and how long to wait between tries.
+var (
+	ReadAttempts = 3
+	RetryDelay   = 500 * time.Millisecond
+)
+
+func NewPool() *Pool {
+	return new(Pool)
+}
+
+// Add puts a node in the pool.  The first node added is used until the
+// first health check.
+func (p *Pool) Add(name string, c Client) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	e := &Endpoint{Name: name, Client: c}
+	p.endpoints = append(p.endpoints, e)
+	if p.current == nil {
+		p.current = e
+	}
+}
+
+// Current returns the name of the node in use.
+func (p *Pool) Current() string {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	if p.current == nil {
+		return ""
+	}
+	return p.current.Name
+}
+
+// Endpoints returns the nodes in the pool, healthiest first.
+func (p *Pool) Endpoints() []Endpoint {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	list := make([]Endpoint, 0, len(p.endpoints))
+	for _, e := range p.ranked() {
+		list = append(list, *e)
+	}
+	return list
+}
+
+// Run health checks the pool every interval, until the context is
+// cancelled.
+func (p *Pool) Run(ctx context.Context, interval time.Duration) {
+	for {
+		p.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Check health checks every node in the pool, and picks the healthiest.
+func (p *Pool) Check(ctx context.Context) {
+	p.mutex.RLock()
+	endpoints := append([]*Endpoint(nil), p.endpoints...)
+	p.mutex.RUnlock()
+
+	type result struct {
+		height  uint32
+		latency time.Duration
+		err     error
+	}
+	results := make([]result, len(endpoints))
+	var wg sync.WaitGroup
+	for i, e := range endpoints {
+		wg.Add(1)
+		go func(i int, c Client) {
+			defer wg.Done()
+			start := time.Now()
+			height, err := blockHeight(ctx, c)
+			results[i] = result{height, time.Since(start), err}
+		}(i, e.Client)
+	}
+	wg.Wait()
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	now := time.Now()
+	for i, e := range endpoints {
+		e.Height, e.Latency, e.Err = results[i].height, results[i].latency, results[i].err
+		e.Checked = now
+	}
+	p.pick()
+}
+
+func blockHeight(ctx context.Context, c Client) (uint32, error) {
+	head, err := c.DirectoryBlockHead(ctx)
+	if err != nil {
+		return 0, err
+	}
+	db, err := c.DirectoryBlock(ctx, head)
+	if err != nil {
+		return 0, err
+	}
+	return db.GetHeader().GetDBHeight(), nil
+}
+
+// ranked returns the endpoints healthiest first.  The pool must be locked.
+func (p *Pool) ranked() []*Endpoint {
+	list := append([]*Endpoint(nil), p.endpoints...)
+	sort.Stable(byHealth(list))
+	return list
+}
+
+// pick makes the healthiest node current, if any of them are healthy.  The
+// pool must be locked.
+func (p *Pool) pick() {
+	list := p.ranked()
+	if len(list) > 0 && list[0].Healthy() {
+		p.current = list[0]
+	}
+}
+
+// failed marks a node that couldn't be reached as unhealthy, and moves off
+// it.
+func (p *Pool) failed(e *Endpoint, err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	e.Err = err
+	p.pick()
+}
+
+func (p *Pool) node() (*Endpoint, error) {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+	if p.current == nil {
+		return nil, fmt.Errorf("No factomd nodes configured")
+	}
+	return p.current, nil
+}
+
+// read runs f on the current node, and again on the healthiest node left
+// if it can't be reached.
+func (p *Pool) read(ctx context.Context, f func(Client) error) error {
+	var err error
+	for i := 0; i < ReadAttempts; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(RetryDelay):
+			}
+		}
+		e, nerr := p.node()
+		if nerr != nil {
+			return nerr
+		}
+		err = f(e.Client)
+		if err == nil || IsRejection(err) || ctx.Err() != nil {
+			return err
+		}
+		p.failed(e, err)
+	}
+	return err
+}
+
+// write runs f on the current node, then on the other nodes healthiest
+// first while they are Unreachable.  No node is tried twice.
+func (p *Pool) write(ctx context.Context, f func(Client) error) error {
+	first, err := p.node()
+	if err != nil {
+		return err
+	}
+	p.mutex.RLock()
+	list := append([]*Endpoint{first}, p.ranked()...)
+	p.mutex.RUnlock()
+
+	for i, e := range list {
+		if i > 0 && e == first {
+			continue
+		}
+		err = f(e.Client)
+		if err == nil || IsRejection(err) || ctx.Err() != nil {
+			return err
+		}
+		p.failed(e, err)
+		if !IsUnreachable(err) {
+			return fmt.Errorf("%v. It may have gone through on %s, so it was not sent to another node", err, e.Name)
+		}
+	}
+	return err
+}
+
+func (p *Pool) FactoidBalance(ctx context.Context, address string) (balance int64, err error) {
+	err = p.read(ctx, func(c Client) (err error) {
+		balance, err = c.FactoidBalance(ctx, address)
+		return
+	})
+	return
+}
+
+func (p *Pool) ECBalance(ctx context.Context, address string) (balance int64, err error) {
+	err = p.read(ctx, func(c Client) (err error) {
+		balance, err = c.ECBalance(ctx, address)
+		return
+	})
+	return
+}
+
+func (p *Pool) GetFee(ctx context.Context) (fee int64, err error) {
+	err = p.read(ctx, func(c Client) (err error) {
+		fee, err = c.GetFee(ctx)
+		return
+	})
+	return
+}
+
+func (p *Pool) GetProperties(ctx context.Context) (protocol string, factomd string, err error) {
+	err = p.read(ctx, func(c Client) (err error) {
+		protocol, factomd, err = c.GetProperties(ctx)
+		return
+	})
+	return
+}
+
+func (p *Pool) FactoidSubmit(ctx context.Context, trans []byte) error {
+	return p.write(ctx, func(c Client) error {
+		return c.FactoidSubmit(ctx, trans)
+	})
+}
+
+func (p *Pool) CommitChain(ctx context.Context, msg []byte) error {
+	return p.write(ctx, func(c Client) error {
+		return c.CommitChain(ctx, msg)
+	})
+}
+
+func (p *Pool) CommitEntry(ctx context.Context, msg []byte) error {
+	return p.write(ctx, func(c Client) error {
+		return c.CommitEntry(ctx, msg)
+	})
+}
+
+func (p *Pool) RevealEntry(ctx context.Context, entry []byte) error {
+	return p.write(ctx, func(c Client) error {
+		return c.RevealEntry(ctx, entry)
+	})
+}
+
+func (p *Pool) DirectoryBlockHead(ctx context.Context) (keymr string, err error) {
+	err = p.read(ctx, func(c Client) (err error) {
+		keymr, err = c.DirectoryBlockHead(ctx)
+		return
+	})
+	return
+}
+
+func (p *Pool) DirectoryBlock(ctx context.Context, keymr string) (db interfaces.IDirectoryBlock, err error) {
+	err = p.read(ctx, func(c Client) (err error) {
+		db, err = c.DirectoryBlock(ctx, keymr)
+		return
+	})
+	return
+}
+
+func (p *Pool) FactoidBlock(ctx context.Context, keymr string) (fb interfaces.IFBlock, err error) {
+	err = p.read(ctx, func(c Client) (err error) {
+		fb, err = c.FactoidBlock(ctx, keymr)
+		return
+	})
+	return
+}
+
+func (p *Pool) EntryCreditBlock(ctx context.Context, keymr string) (ecb interfaces.IEntryCreditBlock, err error) {
+	err = p.read(ctx, func(c Client) (err error) {
+		ecb, err = c.EntryCreditBlock(ctx, keymr)
+		return
+	})
+	return
+}
+
+// Healthy nodes first, then the highest, then the quickest.
+type byHealth []*Endpoint
+
+func (e byHealth) Len() int      { return len(e) }
+func (e byHealth) Swap(i, j int) { e[i], e[j] = e[j], e[i] }
+func (e byHealth) Less(i, j int) bool {
+	if e[i].Healthy() != e[j].Healthy() {
+		return e[i].Healthy()
+	}
+	if e[i].Height != e[j].Height {
+		return e[i].Height > e[j].Height
+	}
+	return e[i].Latency < e[j].Latency
+}
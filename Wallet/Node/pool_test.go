@@ -0,0 +1,152 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package Node_test
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/FactomProject/ed25519"
+	"github.com/FactomProject/fctwallet2/Wallet/Node"
+)
+
+func produceBlocks(t *testing.T, m *Node.Mock, n int) {
+	for i := 0; i < n; i++ {
+		if _, err := m.ProduceBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPoolFailover(t *testing.T) {
+	ctx := context.Background()
+	low, high := Node.NewMock(1000), Node.NewMock(1000)
+	produceBlocks(t, low, 2)
+	produceBlocks(t, high, 3)
+
+	p := Node.NewPool()
+	p.Add("low:8088", low)
+	p.Add("high:8088", high)
+	if p.Current() != "low:8088" {
+		t.Errorf("Using %s before the first health check", p.Current())
+	}
+	p.Check(ctx)
+	if p.Current() != "high:8088" {
+		t.Errorf("Using %s, expected the highest node", p.Current())
+	}
+	if list := p.Endpoints(); list[0].Height != 2 || list[1].Height != 1 {
+		t.Errorf("Heights are %d and %d, expected 2 and 1", list[0].Height, list[1].Height)
+	}
+
+	pub, pri, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := make([]byte, 40)
+	msg[39] = 1
+	commit := append(append(msg, pub[:]...), ed25519.Sign(pri, msg)[:]...)
+
+	// A commit the node turns down isn't sent anywhere else.
+	if err := p.CommitEntry(ctx, commit); !Node.IsRejection(err) {
+		t.Errorf("Expected the commit to be rejected, got %v", err)
+	}
+	if low.Commits() != 0 {
+		t.Error("A rejected commit was sent to another node")
+	}
+
+	// A commit to a node that is down goes to the next one.
+	low.FundEC(hex.EncodeToString(pub[:]), 1)
+	high.SetDown(true)
+	if err := p.CommitEntry(ctx, commit); err != nil {
+		t.Fatal(err)
+	}
+	if low.Commits() != 1 {
+		t.Error("The commit did not fail over")
+	}
+	if p.Current() != "low:8088" {
+		t.Errorf("Still using %s after it went down", p.Current())
+	}
+
+	high.SetDown(false)
+	p.Check(ctx)
+	if p.Current() != "high:8088" {
+		t.Errorf("Using %s, expected to go back to the highest node", p.Current())
+	}
+}
+
+// lostReply is a node that takes commits, but whose replies never arrive.
+type lostReply struct {
+	*Node.Mock
+}
+
+func (n lostReply) CommitEntry(ctx context.Context, msg []byte) error {
+	if err := n.Mock.CommitEntry(ctx, msg); err != nil {
+		return err
+	}
+	return fmt.Errorf("Timed out waiting for the reply")
+}
+
+func TestPoolWriteNotRepeated(t *testing.T) {
+	ctx := context.Background()
+	first, second := Node.NewMock(1000), Node.NewMock(1000)
+
+	p := Node.NewPool()
+	p.Add("first:8088", lostReply{first})
+	p.Add("second:8088", second)
+
+	pub, pri, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := make([]byte, 40)
+	msg[39] = 1
+	commit := append(append(msg, pub[:]...), ed25519.Sign(pri, msg)[:]...)
+	for _, m := range []*Node.Mock{first, second} {
+		m.FundEC(hex.EncodeToString(pub[:]), 1)
+	}
+
+	// The first node may have the commit, so it isn't sent again.
+	if err := p.CommitEntry(ctx, commit); err == nil || Node.IsRejection(err) || Node.IsUnreachable(err) {
+		t.Errorf("Expected the lost reply to be reported, got %v", err)
+	}
+	if first.Commits() != 1 || second.Commits() != 0 {
+		t.Errorf("The commit reached %d and %d nodes, expected only the first",
+			first.Commits(), second.Commits())
+	}
+}
+
+func TestPoolReads(t *testing.T) {
+	ctx := context.Background()
+	adr := "dceb1ce5778444e7777172e1f586488d2382fb1037887cd79a70b0cba4fb3dce"
+	first, second := Node.NewMock(1000), Node.NewMock(1000)
+	for _, m := range []*Node.Mock{first, second} {
+		if err := m.Fund(adr, 100); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	p := Node.NewPool()
+	p.Add("first:8088", first)
+	p.Add("second:8088", second)
+
+	// A read that can't reach a node is tried again on the next one.
+	first.SetDown(true)
+	if bal, err := p.FactoidBalance(ctx, adr); err != nil || bal != 100 {
+		t.Errorf("Balance is %d, expected 100: %v", bal, err)
+	}
+	if p.Current() != "second:8088" {
+		t.Errorf("Still using %s after it went down", p.Current())
+	}
+
+	// Nothing is tried once the caller gives up.
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := p.FactoidBalance(cancelled, adr); err != context.Canceled {
+		t.Errorf("Expected the read to be cancelled, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package Node
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// How long a single request to factomd may take before it is given up on.
+var DefaultTimeout = 10 * time.Second
+
+// send makes an HTTP request to factomd, and returns the body and status
+// of the reply.  It gives up when the context is cancelled or the timeout
+// passes, whichever is first.  Failing to connect is Unreachable.
+func send(ctx context.Context, timeout time.Duration, method string, url string, body []byte) ([]byte, int, error) {
+	var r io.Reader
+	if body != nil {
+		r = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, url, r)
+	if err != nil {
+		return nil, 0, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		if dialError(err) {
+			return nil, 0, Unreachable(err.Error())
+		}
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, resp.StatusCode, nil
+}
+
+// dialError is true if the error from an HTTP request came from connecting,
+// before anything was written to the connection.
+func dialError(err error) bool {
+	if uerr, ok := err.(*url.Error); ok {
+		err = uerr.Err
+	}
+	operr, ok := err.(*net.OpError)
+	return ok && operr.Op == "dial"
+}
+
+// postError reports a post that failed, keeping whether it got to factomd.
+func postError(err error) error {
+	msg := fmt.Sprintf("Could not post to server: %v", err)
+	if IsUnreachable(err) {
+		return Unreachable(msg)
+	}
+	return fmt.Errorf("%s", msg)
+}
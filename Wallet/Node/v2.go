@@ -0,0 +1,233 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package Node
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/FactomProject/factomd/common/directoryBlock"
+	"github.com/FactomProject/factomd/common/entryCreditBlock"
+	"github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// An RPCClient talks to factomd over its v2 JSON-RPC API.
+type RPCClient struct {
+	Address string
+	Port    int
+	Timeout time.Duration
+}
+
+var _ Client = (*RPCClient)(nil)
+
+func NewRPCClient(address string, port int) *RPCClient {
+	return &RPCClient{Address: address, Port: port, Timeout: DefaultTimeout}
+}
+
+// JSON-RPC error codes, and the ones factomd adds.
+const (
+	ErrorParse          = -32700
+	ErrorInvalidRequest = -32600
+	ErrorMethodNotFound = -32601
+	ErrorInvalidParams  = -32602
+	ErrorInternal       = -32603
+	ErrorNotFound       = -32008
+	ErrorRepeatedCommit = -32011
+)
+
+// An RPCError is the error in a JSON-RPC reply from factomd.
+type RPCError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	if e.Data != nil {
+		return fmt.Sprintf("%s: %v", e.Message, e.Data)
+	}
+	return e.Message
+}
+
+// IsNotFound is true if factomd replied that what was asked for doesn't
+// exist.
+func IsNotFound(err error) bool {
+	e, ok := err.(*RPCError)
+	return ok && e.Code == ErrorNotFound
+}
+
+// call sends a request for the method, and unmarshals the result of the
+// reply into result.
+func (c *RPCClient) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	j, err := json.Marshal(primitives.NewJSON2Request(1, params, method))
+	if err != nil {
+		return err
+	}
+	body, status, err := send(ctx, c.Timeout, "POST", fmt.Sprintf("http://%s:%d/v2", c.Address, c.Port), j)
+	if err != nil {
+		return postError(err)
+	}
+
+	r := new(struct {
+		Result json.RawMessage `json:"result"`
+		Error  *RPCError       `json:"error"`
+	})
+	if err := json.Unmarshal(body, r); err != nil {
+		if status != http.StatusOK {
+			return fmt.Errorf("%s", body)
+		}
+		return err
+	}
+	if r.Error != nil {
+		return r.Error
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(r.Result, result)
+}
+
+type addressRequest struct {
+	Address string `json:"address"`
+}
+
+type messageRequest struct {
+	Message string `json:"message"`
+}
+
+type entryRequest struct {
+	Entry string `json:"entry"`
+}
+
+func (c *RPCClient) balance(ctx context.Context, method string, address string) (int64, error) {
+	r := new(struct {
+		Balance int64 `json:"balance"`
+	})
+	if err := c.call(ctx, method, addressRequest{address}, r); err != nil {
+		return 0, err
+	}
+	return r.Balance, nil
+}
+
+func (c *RPCClient) FactoidBalance(ctx context.Context, address string) (int64, error) {
+	adr, err := hex.DecodeString(address)
+	if err != nil {
+		return 0, err
+	}
+	return c.balance(ctx, "factoid-balance", primitives.ConvertFctAddressToUserStr(factoid.NewAddress(adr)))
+}
+
+func (c *RPCClient) ECBalance(ctx context.Context, address string) (int64, error) {
+	adr, err := hex.DecodeString(address)
+	if err != nil {
+		return 0, err
+	}
+	return c.balance(ctx, "entry-credit-balance", primitives.ConvertECAddressToUserStr(factoid.NewAddress(adr)))
+}
+
+func (c *RPCClient) GetFee(ctx context.Context) (int64, error) {
+	r := new(struct {
+		Rate int64 `json:"rate"`
+	})
+	if err := c.call(ctx, "entry-credit-rate", nil, r); err != nil {
+		return 0, err
+	}
+	return r.Rate, nil
+}
+
+func (c *RPCClient) GetProperties(ctx context.Context) (string, string, error) {
+	r := new(struct {
+		FactomdVersion string `json:"factomdversion"`
+		APIVersion     string `json:"factomdapiversion"`
+	})
+	if err := c.call(ctx, "properties", nil, r); err != nil {
+		return "", "", err
+	}
+	return r.APIVersion, r.FactomdVersion, nil
+}
+
+func (c *RPCClient) FactoidSubmit(ctx context.Context, trans []byte) error {
+	req := struct {
+		Transaction string `json:"transaction"`
+	}{hex.EncodeToString(trans)}
+	return c.call(ctx, "factoid-submit", req, nil)
+}
+
+func (c *RPCClient) CommitChain(ctx context.Context, msg []byte) error {
+	return c.call(ctx, "commit-chain", messageRequest{hex.EncodeToString(msg)}, nil)
+}
+
+func (c *RPCClient) CommitEntry(ctx context.Context, msg []byte) error {
+	return c.call(ctx, "commit-entry", messageRequest{hex.EncodeToString(msg)}, nil)
+}
+
+func (c *RPCClient) RevealEntry(ctx context.Context, entry []byte) error {
+	return c.call(ctx, "reveal-entry", entryRequest{hex.EncodeToString(entry)}, nil)
+}
+
+func (c *RPCClient) DirectoryBlockHead(ctx context.Context) (string, error) {
+	r := new(struct {
+		KeyMR string `json:"keymr"`
+	})
+	if err := c.call(ctx, "directory-block-head", nil, r); err != nil {
+		return "", err
+	}
+	return r.KeyMR, nil
+}
+
+func (c *RPCClient) rawData(ctx context.Context, keymr string) ([]byte, error) {
+	req := struct {
+		Hash string `json:"hash"`
+	}{keymr}
+	r := new(struct {
+		Data string `json:"data"`
+	})
+	if err := c.call(ctx, "raw-data", req, r); err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(r.Data)
+}
+
+func (c *RPCClient) DirectoryBlock(ctx context.Context, keymr string) (interfaces.IDirectoryBlock, error) {
+	data, err := c.rawData(ctx, keymr)
+	if err != nil {
+		return nil, err
+	}
+	db := new(directoryBlock.DirectoryBlock)
+	if err := db.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func (c *RPCClient) FactoidBlock(ctx context.Context, keymr string) (interfaces.IFBlock, error) {
+	data, err := c.rawData(ctx, keymr)
+	if err != nil {
+		return nil, err
+	}
+	fb := new(factoid.FBlock)
+	if err := fb.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return fb, nil
+}
+
+func (c *RPCClient) EntryCreditBlock(ctx context.Context, keymr string) (interfaces.IEntryCreditBlock, error) {
+	data, err := c.rawData(ctx, keymr)
+	if err != nil {
+		return nil, err
+	}
+	ecb := entryCreditBlock.NewECBlock()
+	if err := ecb.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return ecb, nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package Node_test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/FactomProject/fctwallet2/Wallet/Node"
+)
+
+// newRPCServer serves canned JSON-RPC replies by method.
+func newRPCServer(t *testing.T, replies map[string]string) (*httptest.Server, *Node.RPCClient) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := new(struct {
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		})
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			t.Error(err)
+			return
+		}
+		if r.URL.Path != "/v2" {
+			t.Errorf("Request sent to %s", r.URL.Path)
+		}
+		reply, ok := replies[req.Method]
+		if !ok {
+			reply = `"error": {"code": -32601, "message": "Method not found"}`
+		}
+		w.Write([]byte(`{"jsonrpc": "2.0", "id": 1, ` + reply + `}`))
+	}))
+	host, port, err := net.SplitHostPort(strings.TrimPrefix(s.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, _ := strconv.Atoi(port)
+	return s, Node.NewRPCClient(host, p)
+}
+
+func TestRPCClient(t *testing.T) {
+	ctx := context.Background()
+	s, c := newRPCServer(t, map[string]string{
+		"factoid-balance":   `"result": {"balance": 1234}`,
+		"entry-credit-rate": `"result": {"rate": 1000}`,
+		"factoid-submit":    `"error": {"code": -32602, "message": "Invalid params", "data": "bad transaction"}`,
+		"raw-data":          `"error": {"code": -32008, "message": "Object not found"}`,
+		"reveal-entry":      `"result": {"message": "Entry Reveal Success"}`,
+	})
+	defer s.Close()
+
+	bal, err := c.FactoidBalance(ctx, "dceb1ce5778444e7777172e1f586488d2382fb1037887cd79a70b0cba4fb3dce")
+	if err != nil || bal != 1234 {
+		t.Errorf("Balance is %d, expected 1234: %v", bal, err)
+	}
+	rate, err := c.GetFee(ctx)
+	if err != nil || rate != 1000 {
+		t.Errorf("Rate is %d, expected 1000: %v", rate, err)
+	}
+
+	err = c.FactoidSubmit(ctx, []byte{1, 2, 3})
+	if e, ok := err.(*Node.RPCError); !ok || e.Code != Node.ErrorInvalidParams {
+		t.Errorf("Expected an invalid params error, got %v", err)
+	} else if e.Error() != "Invalid params: bad transaction" {
+		t.Errorf("Unexpected error message %q", e.Error())
+	}
+
+	if err := c.RevealEntry(ctx, []byte{1, 2, 3}); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := c.DirectoryBlock(ctx, "00"); !Node.IsNotFound(err) {
+		t.Errorf("Expected a not found error, got %v", err)
+	}
+	if _, err := c.DirectoryBlockHead(ctx); err == nil {
+		t.Error("Expected an error for an unknown method")
+	}
+}
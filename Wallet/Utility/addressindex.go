@@ -0,0 +1,348 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package Utility
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/FactomProject/factomd/common/entryCreditBlock"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// As blocks are synced, their transactions are indexed by each address
+// they pay from or to, and by transaction ID.  Entry commits and chain
+// commits are indexed by the entry credit address that pays for them.  Each
+// address has its own bucket, so the history and balance of an address are
+// read without looking at the rest of the chain.
+
+var (
+	addressIndexPrefix = []byte("index.address.")
+	txIndexBucket      = []byte("index.tx")
+)
+
+// The height of an index entry that was rolled back.  The store can't
+// delete in a batch, so a rolled back entry is overwritten instead, in the
+// same batch as the move of the cursor.  It is above any cursor, so it is
+// skipped like the entries of a sync that didn't finish.
+const rolledBack = math.MaxUint32
+
+// Directions of an AddressTx
+const (
+	Received  = 1
+	Sent      = 2
+	Committed = 3 // Entry credits spent on a commit
+)
+
+// An AddressTx is a transaction that pays to or from an address, or a
+// commit paid for by an entry credit address.  Amount is in factoshis for a
+// Factoid address, and in entry credits for an entry credit address.  A
+// transaction that both pays from and to an address has an AddressTx for
+// each direction.  The TxID of a commit is the hash of the entry committed.
+type AddressTx struct {
+	Height    uint32 // of the directory block
+	Index     uint32 // of the transaction in its Factoid block, where 0 is the coinbase, or of the commit in its entry credit block
+	Direction byte
+	Amount    uint64
+	Time      uint64 // in milliseconds
+	TxID      []byte
+}
+
+var _ interfaces.BinaryMarshallableAndCopyable = (*AddressTx)(nil)
+
+const addressTxSize = 4 + 4 + 1 + 8 + 8 + 32
+
+func (a *AddressTx) New() interfaces.BinaryMarshallableAndCopyable {
+	return new(AddressTx)
+}
+
+func (a *AddressTx) MarshalBinary() ([]byte, error) {
+	if len(a.TxID) != 32 {
+		return nil, fmt.Errorf("Transaction ID is %d bytes, expected 32", len(a.TxID))
+	}
+	data := make([]byte, addressTxSize-32, addressTxSize)
+	binary.BigEndian.PutUint32(data[0:], a.Height)
+	binary.BigEndian.PutUint32(data[4:], a.Index)
+	data[8] = a.Direction
+	binary.BigEndian.PutUint64(data[9:], a.Amount)
+	binary.BigEndian.PutUint64(data[17:], a.Time)
+	return append(data, a.TxID...), nil
+}
+
+func (a *AddressTx) UnmarshalBinaryData(data []byte) ([]byte, error) {
+	if len(data) < addressTxSize {
+		return nil, fmt.Errorf("Address index entry is %d bytes, expected %d", len(data), addressTxSize)
+	}
+	a.Height = binary.BigEndian.Uint32(data[0:])
+	a.Index = binary.BigEndian.Uint32(data[4:])
+	a.Direction = data[8]
+	a.Amount = binary.BigEndian.Uint64(data[9:])
+	a.Time = binary.BigEndian.Uint64(data[17:])
+	a.TxID = append([]byte(nil), data[25:addressTxSize]...)
+	return data[addressTxSize:], nil
+}
+
+func (a *AddressTx) UnmarshalBinary(data []byte) error {
+	_, err := a.UnmarshalBinaryData(data)
+	return err
+}
+
+// key sorts the entries of an address by block, then by transaction.  The
+// index of a commit is in the entry credit block, so it can be that of a
+// transaction too; the direction tells them apart.
+func (a *AddressTx) key() []byte {
+	key := make([]byte, 9)
+	binary.BigEndian.PutUint32(key[0:], a.Height)
+	binary.BigEndian.PutUint32(key[4:], a.Index)
+	key[8] = a.Direction
+	return key
+}
+
+func addressBucket(address []byte) []byte {
+	return append(append([]byte(nil), addressIndexPrefix...), address...)
+}
+
+// indexRecords returns the index records of the transactions in the
+// Factoid block, and of the commits in the entry credit block.
+func indexRecords(height uint32, fb interfaces.IFBlock, ecb interfaces.IEntryCreditBlock) []interfaces.Record {
+	var records []interfaces.Record
+	for i, t := range fb.GetTransactions() {
+		txid := t.GetSigHash().Bytes()
+		entries := make(map[string]*AddressTx)
+		add := func(address []byte, direction byte, amount uint64) {
+			k := string(append(append([]byte(nil), address...), direction))
+			e, ok := entries[k]
+			if !ok {
+				e = &AddressTx{
+					Height:    height,
+					Index:     uint32(i),
+					Direction: direction,
+					Time:      t.GetMilliTimestamp(),
+					TxID:      txid,
+				}
+				entries[k] = e
+			}
+			e.Amount += amount
+		}
+		for _, in := range t.GetInputs() {
+			add(in.GetAddress().Bytes(), Sent, in.GetAmount())
+		}
+		for _, out := range t.GetOutputs() {
+			add(out.GetAddress().Bytes(), Received, out.GetAmount())
+		}
+		for _, ec := range t.GetECOutputs() {
+			add(ec.GetAddress().Bytes(), Received, ec.GetAmount()/fb.GetExchRate())
+		}
+
+		for k, e := range entries {
+			address := []byte(k[:len(k)-1])
+			records = append(records, interfaces.Record{Bucket: addressBucket(address), Key: e.key(), Data: e})
+		}
+		loc := &AddressTx{Height: height, Index: uint32(i), Time: t.GetMilliTimestamp(), TxID: txid}
+		records = append(records, interfaces.Record{Bucket: txIndexBucket, Key: txid, Data: loc})
+	}
+
+	for i, e := range ecb.GetBody().GetEntries() {
+		c := &AddressTx{Height: height, Index: uint32(i), Direction: Committed}
+		var address []byte
+		switch e := e.(type) {
+		case *entryCreditBlock.CommitEntry:
+			address = e.ECPubKey[:]
+			c.Amount = uint64(e.Credits)
+			c.Time = milliTime(e.MilliTime)
+			c.TxID = e.EntryHash.Bytes()
+		case *entryCreditBlock.CommitChain:
+			address = e.ECPubKey[:]
+			c.Amount = uint64(e.Credits)
+			c.Time = milliTime(e.MilliTime)
+			c.TxID = e.EntryHash.Bytes()
+		default:
+			// Purchases are indexed from the Factoid block
+			continue
+		}
+		records = append(records, interfaces.Record{Bucket: addressBucket(address), Key: c.key(), Data: c})
+	}
+	return records
+}
+
+// milliTime converts the timestamp of a commit to milliseconds.
+func milliTime(t *primitives.ByteSlice6) uint64 {
+	var ms uint64
+	for _, b := range t[:] {
+		ms = ms<<8 | uint64(b)
+	}
+	return ms
+}
+
+// ProcessFB indexes the transactions of the Factoid block and the commits
+// of the entry credit block synced at the height, and moves the cursor to
+// their directory block, in one database transaction.
+func ProcessFB(height uint32, db interfaces.IDirectoryBlock, fb interfaces.IFBlock, ecb interfaces.IEntryCreditBlock) error {
+	s, err := getStore()
+	if err != nil {
+		return err
+	}
+	cursor := &Cursor{Height: height, KeyMR: db.GetKeyMR().Bytes()}
+	records := append(indexRecords(height, fb, ecb), interfaces.Record{Bucket: syncBucket, Key: cursorKey, Data: cursor})
+	return s.PutInBatch(records)
+}
+
+// RollbackFB undoes ProcessFB, for a block dropped when the node switches
+// to a fork, and moves the cursor back to the block before it, in one
+// database transaction.
+func RollbackFB(height uint32, fb interfaces.IFBlock, ecb interfaces.IEntryCreditBlock) error {
+	s, err := getStore()
+	if err != nil {
+		return err
+	}
+	records := indexRecords(height, fb, ecb)
+	for i := range records {
+		records[i].Data = &AddressTx{Height: rolledBack, TxID: make([]byte, 32)}
+	}
+
+	if height == 0 {
+		// The cursor can't be deleted in the batch.  Until it is, the
+		// first block counts as synced with nothing in it, and a sync
+		// that fails in between rolls it back again.
+		if err := s.PutInBatch(records); err != nil {
+			return err
+		}
+		return deleteCursor()
+	}
+	db, err := GetDirectoryBlock(height - 1)
+	if err != nil {
+		return err
+	}
+	if db == nil {
+		return fmt.Errorf("Directory block at height %d is missing from the database", height-1)
+	}
+	cursor := &Cursor{Height: height - 1, KeyMR: db.GetKeyMR().Bytes()}
+	return s.PutInBatch(append(records, interfaces.Record{Bucket: syncBucket, Key: cursorKey, Data: cursor}))
+}
+
+// processBlock processes the blocks saved at the height, with the blocks
+// locked.
+func processBlock(height uint32) error {
+	blockMutex.Lock()
+	defer blockMutex.Unlock()
+
+	db, err := GetDirectoryBlock(height)
+	if err != nil {
+		return err
+	}
+	fb, err := GetFactoidBlock(height)
+	if err != nil {
+		return err
+	}
+	ecb, err := GetECBlock(height)
+	if err != nil {
+		return err
+	}
+	if db == nil || fb == nil || ecb == nil {
+		return fmt.Errorf("Blocks at height %d are missing from the database", height)
+	}
+	return ProcessFB(height, db, fb, ecb)
+}
+
+// GetAddressTxs returns the synced transactions that pay to or from the
+// address, and the commits it paid for, oldest first.
+func GetAddressTxs(address []byte) ([]*AddressTx, error) {
+	blockMutex.RLock()
+	defer blockMutex.RUnlock()
+	return addressTxs(address)
+}
+
+// addressTxs is GetAddressTxs with the blocks already locked.
+func addressTxs(address []byte) ([]*AddressTx, error) {
+	s, err := getStore()
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := GetCursor()
+	if err != nil {
+		return nil, err
+	}
+	if cursor == nil {
+		return nil, ErrNotSynced
+	}
+	values, err := s.GetAll(addressBucket(address), new(AddressTx))
+	if err != nil {
+		return nil, err
+	}
+
+	// Entries above the cursor are from a sync that didn't finish.
+	txs := make([]*AddressTx, 0, len(values))
+	for _, v := range values {
+		if a := v.(*AddressTx); a.Height <= cursor.Height {
+			txs = append(txs, a)
+		}
+	}
+	sort.Sort(byPosition(txs))
+	return txs, nil
+}
+
+// Balance returns the balance of the address as of the synced blocks: what
+// was paid to it, less what was paid from it and spent on commits.  It is
+// in factoshis for a Factoid address, and in entry credits for an entry
+// credit address.  Transactions factomd has accepted but not yet put in a
+// block are not counted.
+func Balance(address []byte) (int64, error) {
+	txs, err := GetAddressTxs(address)
+	if err != nil {
+		return 0, err
+	}
+	var balance int64
+	for _, t := range txs {
+		if t.Direction == Received {
+			balance += int64(t.Amount)
+		} else {
+			balance -= int64(t.Amount)
+		}
+	}
+	return balance, nil
+}
+
+// getTx returns where the transaction with the ID was synced, or nil if it
+// wasn't.  The blocks must be locked.
+func getTx(txid []byte) (*AddressTx, error) {
+	s, err := getStore()
+	if err != nil {
+		return nil, err
+	}
+	cursor, err := GetCursor()
+	if err != nil {
+		return nil, err
+	}
+	if cursor == nil {
+		return nil, ErrNotSynced
+	}
+	v, err := s.Get(txIndexBucket, txid, new(AddressTx))
+	if err != nil || v == nil {
+		return nil, err
+	}
+	if a := v.(*AddressTx); a.Height <= cursor.Height {
+		return a, nil
+	}
+	return nil, nil
+}
+
+// Oldest first, with the commits of a block after its transactions
+type byPosition []*AddressTx
+
+func (a byPosition) Len() int      { return len(a) }
+func (a byPosition) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byPosition) Less(i, j int) bool {
+	if a[i].Height != a[j].Height {
+		return a[i].Height < a[j].Height
+	}
+	if ci, cj := a[i].Direction == Committed, a[j].Direction == Committed; ci != cj {
+		return cj
+	}
+	return bytes.Compare(a[i].key(), a[j].key()) < 0
+}
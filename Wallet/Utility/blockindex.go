@@ -0,0 +1,222 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package Utility
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/FactomProject/factomd/common/directoryBlock"
+	"github.com/FactomProject/factomd/common/entryCreditBlock"
+	"github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/common/interfaces"
+)
+
+// Synced blocks are kept in the wallet's database, so a restart picks up
+// where the last sync left off rather than reading the chain again from
+// the start.  Directory, Factoid and entry credit blocks are keyed by the
+// height of the directory block, and the sync cursor records the last
+// directory block processed.
+
+var (
+	directoryBlockBucket = []byte("blocks.directory")
+	factoidBlockBucket   = []byte("blocks.factoid")
+	ecBlockBucket        = []byte("blocks.ec")
+	syncBucket           = []byte("blocks.sync")
+	cursorKey            = []byte("cursor")
+)
+
+// A Store is the database synced blocks are kept in, such as the wallet's
+// database.
+type Store interface {
+	Get(bucket, key []byte, destination interfaces.BinaryMarshallable) (interfaces.BinaryMarshallable, error)
+	PutInBatch(records []interfaces.Record) error
+	Delete(bucket, key []byte) error
+	GetAll(bucket []byte, sample interfaces.BinaryMarshallableAndCopyable) ([]interfaces.BinaryMarshallableAndCopyable, error)
+}
+
+var store Store
+
+// SetStore sets the database synced blocks are kept in.
+func SetStore(s Store) {
+	store = s
+}
+
+// A Cursor is how far the blocks have been synced: the height and KeyMR
+// of the last directory block processed.
+type Cursor struct {
+	Height uint32
+	KeyMR  []byte
+}
+
+var _ interfaces.BinaryMarshallable = (*Cursor)(nil)
+
+func (c *Cursor) MarshalBinary() ([]byte, error) {
+	if len(c.KeyMR) != 32 {
+		return nil, fmt.Errorf("Sync cursor KeyMR is %d bytes, expected 32", len(c.KeyMR))
+	}
+	data := make([]byte, 4, 36)
+	binary.BigEndian.PutUint32(data, c.Height)
+	return append(data, c.KeyMR...), nil
+}
+
+func (c *Cursor) UnmarshalBinaryData(data []byte) ([]byte, error) {
+	if len(data) < 36 {
+		return nil, fmt.Errorf("Sync cursor is %d bytes, expected 36", len(data))
+	}
+	c.Height = binary.BigEndian.Uint32(data)
+	c.KeyMR = append([]byte(nil), data[4:36]...)
+	return data[36:], nil
+}
+
+func (c *Cursor) UnmarshalBinary(data []byte) error {
+	_, err := c.UnmarshalBinaryData(data)
+	return err
+}
+
+// Heights are stored big endian, so the blocks sort by height.
+func heightKey(height uint32) []byte {
+	key := make([]byte, 4)
+	binary.BigEndian.PutUint32(key, height)
+	return key
+}
+
+func getStore() (Store, error) {
+	if store == nil {
+		return nil, fmt.Errorf("No database to keep blocks in")
+	}
+	return store, nil
+}
+
+// GetCursor returns how far the blocks have been synced, or nil if no
+// blocks have been synced.
+func GetCursor() (*Cursor, error) {
+	s, err := getStore()
+	if err != nil {
+		return nil, err
+	}
+	v, err := s.Get(syncBucket, cursorKey, new(Cursor))
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, nil
+	}
+	return v.(*Cursor), nil
+}
+
+// GetDirectoryBlock returns the synced directory block at the height, or
+// nil if there isn't one.
+func GetDirectoryBlock(height uint32) (interfaces.IDirectoryBlock, error) {
+	s, err := getStore()
+	if err != nil {
+		return nil, err
+	}
+	v, err := s.Get(directoryBlockBucket, heightKey(height), new(directoryBlock.DirectoryBlock))
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, nil
+	}
+	return v.(interfaces.IDirectoryBlock), nil
+}
+
+// GetFactoidBlock returns the Factoid block of the synced directory block
+// at the height, or nil if there isn't one.
+func GetFactoidBlock(height uint32) (interfaces.IFBlock, error) {
+	s, err := getStore()
+	if err != nil {
+		return nil, err
+	}
+	v, err := s.Get(factoidBlockBucket, heightKey(height), new(factoid.FBlock))
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, nil
+	}
+	return v.(interfaces.IFBlock), nil
+}
+
+// GetECBlock returns the entry credit block of the synced directory block
+// at the height, or nil if there isn't one.
+func GetECBlock(height uint32) (interfaces.IEntryCreditBlock, error) {
+	s, err := getStore()
+	if err != nil {
+		return nil, err
+	}
+	v, err := s.Get(ecBlockBucket, heightKey(height), entryCreditBlock.NewECBlock())
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, nil
+	}
+	return v.(interfaces.IEntryCreditBlock), nil
+}
+
+// saveBlocks writes a directory block with its Factoid and entry credit
+// blocks.  Blocks are written as they are read, but only count as synced
+// once the cursor has moved past them.
+func saveBlocks(db interfaces.IDirectoryBlock, fb interfaces.IFBlock, ecb interfaces.IEntryCreditBlock) error {
+	s, err := getStore()
+	if err != nil {
+		return err
+	}
+	key := heightKey(db.GetHeader().GetDBHeight())
+	return s.PutInBatch([]interfaces.Record{
+		{Bucket: directoryBlockBucket, Key: key, Data: db},
+		{Bucket: factoidBlockBucket, Key: key, Data: fb},
+		{Bucket: ecBlockBucket, Key: key, Data: ecb},
+	})
+}
+
+// saveCursor moves the sync cursor.
+func saveCursor(c *Cursor) error {
+	s, err := getStore()
+	if err != nil {
+		return err
+	}
+	return s.PutInBatch([]interfaces.Record{{Bucket: syncBucket, Key: cursorKey, Data: c}})
+}
+
+// deleteCursor marks nothing as synced.
+func deleteCursor() error {
+	s, err := getStore()
+	if err != nil {
+		return err
+	}
+	return s.Delete(syncBucket, cursorKey)
+}
+
+// eachFactoidBlock calls f with each synced Factoid block, oldest first,
+// and the height of its directory block.  The blocks don't change until it
+// returns.
+func eachFactoidBlock(f func(height uint32, fb interfaces.IFBlock) error) error {
+	blockMutex.RLock()
+	defer blockMutex.RUnlock()
+
+	cursor, err := GetCursor()
+	if err != nil {
+		return err
+	}
+	if cursor == nil {
+		return ErrNotSynced
+	}
+	for h := uint32(0); h <= cursor.Height; h++ {
+		fb, err := GetFactoidBlock(h)
+		if err != nil {
+			return err
+		}
+		if fb == nil {
+			return fmt.Errorf("Factoid block at height %d is missing from the database", h)
+		}
+		if err := f(h, fb); err != nil {
+			return err
+		}
+	}
+	return nil
+}
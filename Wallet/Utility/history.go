@@ -0,0 +1,283 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package Utility
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// The history of a set of addresses is read from the address index, newest
+// first, a page at a time.  Each page ends with a cursor to pass back for
+// the next one.  Cursors are positions in the chain rather than counts, so
+// blocks synced between pages don't shift the pages.
+
+// Directions of a HistoryTx, as seen from the addresses it is the history
+// of.
+const (
+	HistoryIn         = "in"
+	HistoryOut        = "out"
+	HistoryECPurchase = "ec"
+	HistoryCommit     = "commit"
+)
+
+// Sizes of a page of history
+const (
+	DefaultHistoryLimit = 50
+	MaxHistoryLimit     = 1000
+)
+
+// A HistoryFilter picks the synced transactions of the addresses to list.
+// Zero values don't filter, so ToHeight and ToTime of 0 have no upper
+// bound.  Times are in milliseconds, and MinAmount is in factoshis, so it
+// is compared with the Amount of each transaction, but not of commits,
+// which are in entry credits.
+type HistoryFilter struct {
+	Addresses  [][]byte
+	FromHeight uint32
+	ToHeight   uint32
+	FromTime   uint64
+	ToTime     uint64
+	Directions []string
+	MinAmount  uint64
+}
+
+// A HistoryIO is an input or output of a transaction.  Amounts are in
+// factoshis, or in entry credits for the entry credit address that paid for
+// a commit.
+type HistoryIO struct {
+	Address string `json:"address"`
+	Amount  uint64 `json:"amount"`
+}
+
+// A HistoryTx is a transaction, or a commit, in the history of a set of
+// addresses.
+//
+// A transaction that buys entry credits, paid for by the addresses or
+// bought for them, is an EC purchase of the factoshis paid for the credits.
+// Otherwise, a transaction the addresses pay into is out by what left them,
+// fee included, and any other is in by what they were paid.  A commit is in
+// entry credits, and its TxID is the hash of the entry committed.
+type HistoryTx struct {
+	TxID      string      `json:"txid"`
+	Height    uint32      `json:"height"`
+	Time      uint64      `json:"time"` // in milliseconds
+	Direction string      `json:"direction"`
+	Amount    uint64      `json:"amount"`
+	Fee       uint64      `json:"fee"`
+	Inputs    []HistoryIO `json:"inputs"`
+	Outputs   []HistoryIO `json:"outputs"`
+	ECOutputs []HistoryIO `json:"ecoutputs"`
+
+	pos []byte
+}
+
+// A HistoryPage is a page of history, newest first.  Next is the cursor of
+// the page after it, or empty if this is the last.  Height is the height
+// of the last directory block synced.
+type HistoryPage struct {
+	Transactions []*HistoryTx `json:"transactions"`
+	Next         string       `json:"next"`
+	Height       uint32       `json:"height"`
+}
+
+// A historyLoc is where an entry of the history was found, and the address
+// whose index it was found in.
+type historyLoc struct {
+	*AddressTx
+	address []byte
+}
+
+// position orders entries like byPosition, with one position per
+// transaction or commit.
+func (a *AddressTx) position() []byte {
+	pos := make([]byte, 9)
+	binary.BigEndian.PutUint32(pos[0:], a.Height)
+	if a.Direction == Committed {
+		pos[4] = 1
+	}
+	binary.BigEndian.PutUint32(pos[5:], a.Index)
+	return pos
+}
+
+// History returns a page of the synced history of the addresses that
+// passes the filter, starting after the cursor, or from the newest if the
+// cursor is empty.  A limit out of range gets the nearest size allowed, or
+// the default if it is 0 or less.
+func History(filter *HistoryFilter, after string, limit int) (*HistoryPage, error) {
+	var from []byte
+	if after != "" {
+		var err error
+		from, err = hex.DecodeString(after)
+		if err != nil || len(from) != 9 {
+			return nil, fmt.Errorf("Invalid history cursor %s", after)
+		}
+	}
+	if limit <= 0 {
+		limit = DefaultHistoryLimit
+	} else if limit > MaxHistoryLimit {
+		limit = MaxHistoryLimit
+	}
+	directions := make(map[string]bool)
+	for _, d := range filter.Directions {
+		switch d {
+		case HistoryIn, HistoryOut, HistoryECPurchase, HistoryCommit:
+			directions[d] = true
+		default:
+			return nil, fmt.Errorf("Unknown direction %s", d)
+		}
+	}
+
+	blockMutex.RLock()
+	defer blockMutex.RUnlock()
+
+	cursor, err := GetCursor()
+	if err != nil {
+		return nil, err
+	}
+	if cursor == nil {
+		return nil, ErrNotSynced
+	}
+
+	set := make(map[string]bool)
+	seen := make(map[string]bool)
+	var locs []historyLoc
+	for _, adr := range filter.Addresses {
+		set[string(adr)] = true
+		txs, err := addressTxs(adr)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range txs {
+			pos := t.position()
+			switch {
+			case seen[string(pos)]:
+			case from != nil && bytes.Compare(pos, from) >= 0:
+			case t.Height < filter.FromHeight || (filter.ToHeight > 0 && t.Height > filter.ToHeight):
+			case t.Time < filter.FromTime || (filter.ToTime > 0 && t.Time > filter.ToTime):
+			default:
+				seen[string(pos)] = true
+				locs = append(locs, historyLoc{t, adr})
+			}
+		}
+	}
+	sort.Sort(sort.Reverse(byHistoryPosition(locs)))
+
+	page := &HistoryPage{Height: cursor.Height}
+	var fb interfaces.IFBlock
+	var fbHeight uint32
+	for _, loc := range locs {
+		var h *HistoryTx
+		if loc.Direction == Committed {
+			h = historyCommit(loc)
+		} else {
+			if fb == nil || fbHeight != loc.Height {
+				fbHeight = loc.Height
+				fb, err = GetFactoidBlock(loc.Height)
+				if err != nil {
+					return nil, err
+				}
+				if fb == nil {
+					return nil, fmt.Errorf("Factoid block at height %d is missing from the database", loc.Height)
+				}
+			}
+			txs := fb.GetTransactions()
+			if int(loc.Index) >= len(txs) {
+				return nil, fmt.Errorf("No transaction %d in the Factoid block at height %d", loc.Index, loc.Height)
+			}
+			h = historyTx(loc, txs[loc.Index], set)
+		}
+		if len(directions) > 0 && !directions[h.Direction] {
+			continue
+		}
+		if h.Direction != HistoryCommit && h.Amount < filter.MinAmount {
+			continue
+		}
+		if len(page.Transactions) == limit {
+			page.Next = hex.EncodeToString(page.Transactions[limit-1].pos)
+			break
+		}
+		page.Transactions = append(page.Transactions, h)
+	}
+	return page, nil
+}
+
+// historyTx describes the Factoid transaction as seen from the set of
+// addresses.
+func historyTx(loc historyLoc, t interfaces.ITransaction, set map[string]bool) *HistoryTx {
+	h := &HistoryTx{
+		TxID:   hex.EncodeToString(loc.TxID),
+		Height: loc.Height,
+		Time:   loc.Time,
+		pos:    loc.position(),
+	}
+	var paid, received, credits, ins, outs, ecs uint64
+	for _, in := range t.GetInputs() {
+		if set[string(in.GetAddress().Bytes())] {
+			paid += in.GetAmount()
+		}
+		ins += in.GetAmount()
+		h.Inputs = append(h.Inputs, HistoryIO{primitives.ConvertFctAddressToUserStr(in.GetAddress()), in.GetAmount()})
+	}
+	for _, out := range t.GetOutputs() {
+		if set[string(out.GetAddress().Bytes())] {
+			received += out.GetAmount()
+		}
+		outs += out.GetAmount()
+		h.Outputs = append(h.Outputs, HistoryIO{primitives.ConvertFctAddressToUserStr(out.GetAddress()), out.GetAmount()})
+	}
+	for _, ec := range t.GetECOutputs() {
+		if set[string(ec.GetAddress().Bytes())] {
+			credits += ec.GetAmount()
+		}
+		ecs += ec.GetAmount()
+		h.ECOutputs = append(h.ECOutputs, HistoryIO{primitives.ConvertECAddressToUserStr(ec.GetAddress()), ec.GetAmount()})
+	}
+	if ins > outs+ecs {
+		h.Fee = ins - outs - ecs
+	}
+
+	switch {
+	case paid > 0 && ecs > 0:
+		h.Direction, h.Amount = HistoryECPurchase, ecs
+	case credits > 0:
+		h.Direction, h.Amount = HistoryECPurchase, credits
+	case paid > received:
+		h.Direction, h.Amount = HistoryOut, paid-received
+	default:
+		h.Direction, h.Amount = HistoryIn, received-paid
+	}
+	return h
+}
+
+// historyCommit describes the commit paid for by the entry credit address
+// it was found under.
+func historyCommit(loc historyLoc) *HistoryTx {
+	adr := primitives.ConvertECAddressToUserStr(factoid.NewAddress(loc.address))
+	return &HistoryTx{
+		TxID:      hex.EncodeToString(loc.TxID),
+		Height:    loc.Height,
+		Time:      loc.Time,
+		Direction: HistoryCommit,
+		Amount:    loc.Amount,
+		Inputs:    []HistoryIO{{adr, loc.Amount}},
+		pos:       loc.position(),
+	}
+}
+
+type byHistoryPosition []historyLoc
+
+func (a byHistoryPosition) Len() int      { return len(a) }
+func (a byHistoryPosition) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a byHistoryPosition) Less(i, j int) bool {
+	return bytes.Compare(a[i].position(), a[j].position()) < 0
+}
@@ -0,0 +1,105 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package Utility
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Blocks are synced from factomd in the background by RunSync.  Anything
+// that reads the synced blocks sees them as of the last sync that
+// finished, and a sync that fails leaves them as they were.
+
+// States of the sync with factomd
+const (
+	SyncStateSyncing = "syncing"
+	SyncStateSynced  = "synced"
+	SyncStateError   = "error"
+)
+
+// ErrNotSynced is returned when the blocks are read before the first sync
+// has finished.
+var ErrNotSynced = fmt.Errorf("The wallet is not yet synced with factomd")
+
+// A SyncStatus is how the sync with factomd is going.  Height is the
+// height of the last directory block synced, if Synced is true.  LastError
+// is the error of the last sync that failed, even if later ones didn't.
+type SyncStatus struct {
+	State     string    `json:"state"`
+	Synced    bool      `json:"synced"`
+	Height    uint32    `json:"height"`
+	LastError string    `json:"lasterror,omitempty"`
+	LastSync  time.Time `json:"lastsync"`
+}
+
+var (
+	statusMutex sync.Mutex
+	status      = SyncStatus{State: SyncStateSyncing}
+
+	// Only one sync runs at a time.  Moving the cursor locks out readers.
+	syncMutex  sync.Mutex
+	blockMutex sync.RWMutex
+)
+
+// GetSyncStatus returns how the sync with factomd is going.
+func GetSyncStatus() SyncStatus {
+	statusMutex.Lock()
+	defer statusMutex.Unlock()
+	return status
+}
+
+func setSyncState(state string) {
+	statusMutex.Lock()
+	defer statusMutex.Unlock()
+	status.State = state
+}
+
+// RunSync syncs the blocks every interval, until the context is cancelled.
+func RunSync(ctx context.Context, interval time.Duration) {
+	for {
+		Sync(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Sync reads any blocks added since the last sync, and records how it
+// went in the sync status.
+func Sync(ctx context.Context) (err error) {
+	syncMutex.Lock()
+	defer syncMutex.Unlock()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("Sync failed: %v", r)
+		}
+		cursor, cerr := GetCursor()
+		if err == nil {
+			err = cerr
+		}
+
+		statusMutex.Lock()
+		defer statusMutex.Unlock()
+		if err != nil {
+			status.State = SyncStateError
+			status.LastError = err.Error()
+		} else {
+			status.State = SyncStateSynced
+			status.LastSync = time.Now()
+		}
+		if cursor != nil {
+			status.Synced = true
+			status.Height = cursor.Height
+		}
+	}()
+
+	return refresh(ctx)
+}
@@ -5,123 +5,252 @@ package Utility
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 
-	"github.com/FactomProject/factom"
 	"github.com/FactomProject/factomd/common/constants"
-	"github.com/FactomProject/factomd/common/directoryBlock"
-	"github.com/FactomProject/factomd/common/factoid"
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/fctwallet2/Wallet/Node"
 )
 
 /************************************************
  * Transaction listing code
  ***********************************************/
 
-// Older blocks smaller indexes.  All the Factoid Directory blocks
-var DirectoryBlocks = make([]*directoryBlock.DirectoryBlock, 0, 100)
-var FactoidBlocks = make([]interfaces.IFBlock, 0, 100)
-var DBHead []byte = constants.ZERO_HASH
-var DBHeadStr string = ""
-var DBHeadLast []byte = constants.ZERO_HASH
+// The factomd the blocks are read from.
+var node Node.Client
 
-// Refresh the Directory Block Head.  If it has changed, return true.
-// Otherwise return false.
-func getDBHead() bool {
-	db, err := factom.GetDBlockHead()
+// SetNode sets the factomd client blocks are read from.
+func SetNode(c Node.Client) {
+	node = c
+}
+
+// A block read from the node, to be saved once the blocks it replaces are
+// rolled back.
+type syncedBlock struct {
+	db  interfaces.IDirectoryBlock
+	fb  interfaces.IFBlock
+	ecb interfaces.IEntryCreditBlock
+}
 
+// getAll reads back from the head until it reaches a block already synced,
+// then processes the new blocks oldest first and moves the cursor to the
+// head.  New blocks are saved as they are read, so blocks saved by a sync
+// that failed or was cancelled are not read again.
+//
+// If the node has switched to a fork, the blocks synced since the fork no
+// longer match the node.  They are rolled back, newest first, and replaced
+// by the blocks of the fork.
+func getAll(ctx context.Context, head string) error {
+	headBytes, err := hex.DecodeString(head)
+	if err != nil {
+		return err
+	}
+	cursor, err := GetCursor()
 	if err != nil {
-		panic(err.Error())
+		return err
+	}
+	// synced is true if the block at the height is synced, and is the one
+	// the node has.
+	synced := func(height uint32, keymr []byte) (bool, error) {
+		if cursor == nil || height > cursor.Height {
+			return false, nil
+		}
+		return isSaved(height, keymr)
 	}
 
-	if db != DBHeadStr {
-		DBHeadStr = db
-		DBHead, err = hex.DecodeString(db)
+	var top, start uint32
+	var fork []syncedBlock
+	next := head
+	for first := true; ; first = false {
+		db, err := node.DirectoryBlock(ctx, next)
 		if err != nil {
-			panic(err.Error())
+			return err
+		}
+		height := db.GetHeader().GetDBHeight()
+		if first {
+			top = height
+			// A node that is behind has nothing new.
+			if ok, err := synced(height, headBytes); err != nil || ok {
+				return err
+			}
 		}
 
-		return true
-	}
-	return false
-}
-
-func getAll() error {
-	dbs := make([]*directoryBlock.DirectoryBlock, 0, 100)
-	next := DBHeadStr
+		if cursor != nil && height <= cursor.Height {
+			fb, ecb, err := readBlocks(ctx, db)
+			if err != nil {
+				return err
+			}
+			fork = append(fork, syncedBlock{db, fb, ecb})
+		} else if err := syncBlock(ctx, db); err != nil {
+			return err
+		}
 
-	for {
-		blk, err := factom.GetRaw(next)
-		if err != nil {
-			panic(err.Error())
+		if height == 0 {
+			break
 		}
-		db := new(directoryBlock.DirectoryBlock)
-		err = db.UnmarshalBinary(blk)
+		prev := db.GetHeader().GetPrevKeyMR().Bytes()
+		ok, err := synced(height-1, prev)
 		if err != nil {
-			panic(err.Error())
+			return err
 		}
-		dbs = append(dbs, db)
-		if bytes.Equal(db.Header.GetPrevKeyMR().Bytes(), DBHeadLast) {
+		if ok {
+			start = height
 			break
 		}
-		next = hex.EncodeToString(db.Header.GetPrevKeyMR().Bytes())
+		next = hex.EncodeToString(prev)
 	}
 
-	DBHeadLast = DBHead
-
-	for i := len(dbs) - 1; i >= 0; i-- {
-		DirectoryBlocks = append(DirectoryBlocks, dbs[i])
-		fb := new(factoid.FBlock)
-		var fcnt int
-		for _, dbe := range dbs[i].DBEntries {
-			if bytes.Equal(dbe.GetChainID().Bytes(), constants.FACTOID_CHAINID) {
-				fcnt++
-				hashstr := hex.EncodeToString(dbe.GetKeyMR().Bytes())
-				fdata, err := factom.GetRaw(hashstr)
-				if err != nil {
-					panic(err.Error())
-				}
-				err = fb.UnmarshalBinary(fdata)
-				if err != nil {
-					panic(err.Error())
-				}
-				FactoidBlocks = append(FactoidBlocks, fb)
-				break
-			}
-		}
-		if fb == nil {
-			panic("Missing Factoid Block from a directory block")
+	// The blocks are locked for one block at a time, so readers wait for
+	// a block rather than the whole sync.  Blocks above the cursor aren't
+	// read, so they are replaced without the lock.
+	if len(fork) > 0 {
+		if err := rollback(start, cursor); err != nil {
+			return err
 		}
-		if fcnt > 1 {
-			panic("More than one Factom Block found in a directory block.")
+		for i := len(fork) - 1; i >= 0; i-- {
+			if err := saveBlocks(fork[i].db, fork[i].fb, fork[i].ecb); err != nil {
+				return err
+			}
 		}
-		if err := ProcessFB(fb); err != nil {
+	}
+	for h := start; h <= top; h++ {
+		if err := processBlock(h); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func refresh() error {
-
-	if getDBHead() {
-		if err := getAll(); err != nil {
+// rollback undoes the processing of the blocks synced from the height up
+// to the cursor, newest first.
+func rollback(from uint32, cursor *Cursor) error {
+	for h := cursor.Height + 1; h > from; h-- {
+		if err := rollbackBlock(h - 1); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
+// rollbackBlock undoes the processing of the blocks at the height, with
+// the blocks locked.
+func rollbackBlock(height uint32) error {
+	blockMutex.Lock()
+	defer blockMutex.Unlock()
+
+	fb, err := GetFactoidBlock(height)
+	if err != nil {
+		return err
+	}
+	ecb, err := GetECBlock(height)
+	if err != nil {
+		return err
+	}
+	if fb == nil || ecb == nil {
+		return fmt.Errorf("Blocks at height %d are missing from the database", height)
+	}
+	return RollbackFB(height, fb, ecb)
+}
+
+// isSaved is true if the directory block saved at the height has the
+// KeyMR.
+func isSaved(height uint32, keymr []byte) (bool, error) {
+	saved, err := GetDirectoryBlock(height)
+	if err != nil || saved == nil {
+		return false, err
+	}
+	return bytes.Equal(saved.GetKeyMR().Bytes(), keymr), nil
+}
+
+// syncBlock reads the Factoid and entry credit blocks of the directory
+// block, and saves all three.  Nothing is read if an earlier sync saved the
+// same block.
+func syncBlock(ctx context.Context, db interfaces.IDirectoryBlock) error {
+	ok, err := isSaved(db.GetHeader().GetDBHeight(), db.GetKeyMR().Bytes())
+	if err != nil || ok {
+		return err
+	}
+	fb, ecb, err := readBlocks(ctx, db)
+	if err != nil {
+		return err
+	}
+	return saveBlocks(db, fb, ecb)
+}
+
+// readBlocks reads the Factoid and entry credit blocks of the directory
+// block from the node.
+func readBlocks(ctx context.Context, db interfaces.IDirectoryBlock) (interfaces.IFBlock, interfaces.IEntryCreditBlock, error) {
+	fkey, err := blockKeyMR(db, constants.FACTOID_CHAINID, "Factoid")
+	if err != nil {
+		return nil, nil, err
+	}
+	eckey, err := blockKeyMR(db, constants.EC_CHAINID, "Entry Credit")
+	if err != nil {
+		return nil, nil, err
+	}
+	fb, err := node.FactoidBlock(ctx, fkey)
+	if err != nil {
+		return nil, nil, err
+	}
+	ecb, err := node.EntryCreditBlock(ctx, eckey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return fb, ecb, nil
+}
+
+// blockKeyMR returns the KeyMR of the one block of the chain in the
+// directory block.
+func blockKeyMR(db interfaces.IDirectoryBlock, chainID []byte, name string) (string, error) {
+	var keymr string
+	var cnt int
+	for _, dbe := range db.GetDBEntries() {
+		if bytes.Equal(dbe.GetChainID().Bytes(), chainID) {
+			cnt++
+			keymr = hex.EncodeToString(dbe.GetKeyMR().Bytes())
+		}
+	}
+	if cnt == 0 {
+		return "", fmt.Errorf("Missing %s Block from directory block %x", name, db.GetKeyMR().Bytes())
+	}
+	if cnt > 1 {
+		return "", fmt.Errorf("More than one %s Block found in directory block %x", name, db.GetKeyMR().Bytes())
+	}
+	return keymr, nil
+}
+
+// refresh syncs any blocks added since the last refresh.  The sync must be
+// locked.
+func refresh(ctx context.Context) error {
+	head, err := node.DirectoryBlockHead(ctx)
+	if err != nil {
+		return err
+	}
+	cursor, err := GetCursor()
+	if err != nil {
+		return err
+	}
+	if cursor != nil && hex.EncodeToString(cursor.KeyMR) == head {
+		return nil
+	}
+	setSyncState(SyncStateSyncing)
+	return getAll(ctx, head)
+}
+
+// GetDBHeight returns the height of the last directory block synced.
 func GetDBHeight() (uint32, error) {
-	if err := refresh(); err != nil {
+	cursor, err := GetCursor()
+	if err != nil {
 		return 0, err
 	}
-
-	h := DirectoryBlocks[len(DirectoryBlocks)-1].GetHeader().GetDBHeight()
-	return h, nil
+	if cursor == nil {
+		return 0, ErrNotSynced
+	}
+	return cursor.Height, nil
 }
 
 func filtertransaction(trans interfaces.ITransaction, addresses [][]byte) bool {
@@ -160,31 +289,110 @@ Search:
 	return true
 }
 
-func DumpTransactionsJSON(addresses [][]byte) ([]byte, error) {
-	if err := refresh(); err != nil {
-		return nil, err
+// HasTransactions is true if the address shows up in any processed factoid
+// transaction, as an input, an output, or an entry credit purchase, or has
+// paid for a commit.
+func HasTransactions(address []byte) (bool, error) {
+	txs, err := GetAddressTxs(address)
+	if err != nil {
+		return false, err
 	}
+	return len(txs) > 0, nil
+}
 
-	var transactions []interfaces.ITransaction
+// eachAddressTransaction calls f with each synced transaction that involves
+// all of the addresses, oldest first, found through the index of the first
+// address.  A single address that has no transactions is looked up as a
+// transaction ID.  Returns the height synced to.
+func eachAddressTransaction(addresses [][]byte, f func(height uint32, index uint32, fb interfaces.IFBlock, t interfaces.ITransaction)) (uint32, error) {
+	blockMutex.RLock()
+	defer blockMutex.RUnlock()
 
-	for i, fb := range FactoidBlocks {
-		for _, t := range fb.GetTransactions() {
-			t.SetBlockHeight(i)
-			t.GetSigHash()
-			for _, input := range t.GetInputs() {
-				input.SetUserAddress(primitives.ConvertFctAddressToUserStr(input.GetAddress()))
-			}
-			for _, output := range t.GetOutputs() {
-				output.SetUserAddress(primitives.ConvertFctAddressToUserStr(output.GetAddress()))
-			}
-			for _, ecoutput := range t.GetECOutputs() {
-				ecoutput.SetUserAddress(primitives.ConvertECAddressToUserStr(ecoutput.GetAddress()))
+	cursor, err := GetCursor()
+	if err != nil {
+		return 0, err
+	}
+	if cursor == nil {
+		return 0, ErrNotSynced
+	}
+	all, err := addressTxs(addresses[0])
+	if err != nil {
+		return 0, err
+	}
+	// Commits are not Factoid transactions
+	locs := all[:0]
+	for _, loc := range all {
+		if loc.Direction != Committed {
+			locs = append(locs, loc)
+		}
+	}
+	if len(locs) == 0 && len(addresses) == 1 {
+		loc, err := getTx(addresses[0])
+		if err != nil {
+			return 0, err
+		}
+		if loc != nil {
+			locs = append(locs, loc)
+		}
+	}
+
+	var fb interfaces.IFBlock
+	for i, loc := range locs {
+		if i > 0 && loc.Height == locs[i-1].Height && loc.Index == locs[i-1].Index {
+			continue // Paid both to and from the address
+		}
+		if i == 0 || loc.Height != locs[i-1].Height {
+			fb, err = GetFactoidBlock(loc.Height)
+			if err != nil {
+				return 0, err
 			}
-			prtTrans := filtertransaction(t, addresses)
-			if prtTrans {
-				transactions = append(transactions, t)
+			if fb == nil {
+				return 0, fmt.Errorf("Factoid block at height %d is missing from the database", loc.Height)
 			}
 		}
+		txs := fb.GetTransactions()
+		if int(loc.Index) >= len(txs) {
+			return 0, fmt.Errorf("No transaction %d in the Factoid block at height %d", loc.Index, loc.Height)
+		}
+		if t := txs[loc.Index]; filtertransaction(t, addresses) {
+			f(loc.Height, loc.Index, fb, t)
+		}
+	}
+	return cursor.Height, nil
+}
+
+func DumpTransactionsJSON(addresses [][]byte) ([]byte, error) {
+	var transactions []interfaces.ITransaction
+	add := func(height uint32, t interfaces.ITransaction) {
+		t.SetBlockHeight(int(height))
+		t.GetSigHash()
+		for _, input := range t.GetInputs() {
+			input.SetUserAddress(primitives.ConvertFctAddressToUserStr(input.GetAddress()))
+		}
+		for _, output := range t.GetOutputs() {
+			output.SetUserAddress(primitives.ConvertFctAddressToUserStr(output.GetAddress()))
+		}
+		for _, ecoutput := range t.GetECOutputs() {
+			ecoutput.SetUserAddress(primitives.ConvertECAddressToUserStr(ecoutput.GetAddress()))
+		}
+		transactions = append(transactions, t)
+	}
+
+	var err error
+	if len(addresses) == 0 {
+		err = eachFactoidBlock(func(height uint32, fb interfaces.IFBlock) error {
+			for _, t := range fb.GetTransactions() {
+				add(height, t)
+			}
+			return nil
+		})
+	} else {
+		_, err = eachAddressTransaction(addresses, func(height uint32, index uint32, fb interfaces.IFBlock, t interfaces.ITransaction) {
+			add(height, t)
+		})
+	}
+	if err != nil {
+		return nil, err
 	}
 
 	ret, err := json.Marshal(transactions)
@@ -193,11 +401,8 @@ func DumpTransactionsJSON(addresses [][]byte) ([]byte, error) {
 }
 
 func TotalFactoids() (uint64, error) {
-	if err := refresh(); err != nil {
-		return 0, err
-	}
 	var total uint64
-	for _, fb := range FactoidBlocks {
+	err := eachFactoidBlock(func(height uint32, fb interfaces.IFBlock) error {
 		for _, t := range fb.GetTransactions() {
 			for _, input := range t.GetInputs() {
 				amt := input.GetAmount()
@@ -208,37 +413,39 @@ func TotalFactoids() (uint64, error) {
 				total += amt
 			}
 		}
-	}
-	return total, nil
+		return nil
+	})
+	return total, err
 }
 
 func TotalEntryCredits() (uint64, error) {
-	if err := refresh(); err != nil {
-		return 0, err
-	}
 	var total uint64
-	for _, fb := range FactoidBlocks {
+	err := eachFactoidBlock(func(height uint32, fb interfaces.IFBlock) error {
 		for _, t := range fb.GetTransactions() {
 			for _, ecoutput := range t.GetECOutputs() {
 				amt := ecoutput.GetAmount() / fb.GetExchRate()
 				total += amt
 			}
 		}
-	}
-	return total, nil
+		return nil
+	})
+	return total, err
 }
 
 func DumpTransactions(addresses [][]byte) ([]byte, error) {
-	var ret bytes.Buffer
-	if err := refresh(); err != nil {
-		return nil, err
+	if len(addresses) > 0 {
+		return dumpAddressTransactions(addresses)
 	}
+
+	var ret bytes.Buffer
 	usertranscnt := 0
 	firstemptyblock := 0
 	coinbasetranscnt := 0
 	skippedblk := false
+	i := -1
 
-	for i, fb := range FactoidBlocks {
+	err := eachFactoidBlock(func(height uint32, fb interfaces.IFBlock) error {
+		i = int(height)
 		var out bytes.Buffer
 
 		blkempty := true
@@ -285,8 +492,11 @@ func DumpTransactions(addresses [][]byte) ([]byte, error) {
 		if !blkempty {
 			ret.WriteString(out.String())
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	i := len(FactoidBlocks) - 1
 	if skippedblk {
 		if i == firstemptyblock {
 			ret.WriteString(fmt.Sprintf("Skipped block %d\n\n", firstemptyblock))
@@ -297,7 +507,80 @@ func DumpTransactions(addresses [][]byte) ([]byte, error) {
 	return ret.Bytes(), nil
 }
 
-// At some point we will need to be smarter... Process Blocks and transactions here!
-func ProcessFB(fb interfaces.IFBlock) error {
-	return nil
+// dumpAddressTransactions is DumpTransactions for the transactions of the
+// addresses, read through the index.  Transactions are numbered the same,
+// by their place among all the transactions synced but the coinbases, so
+// the blocks in between are read to count theirs.
+func dumpAddressTransactions(addresses [][]byte) ([]byte, error) {
+	var ret, out bytes.Buffer
+	var next, height uint32
+	shown := false
+
+	usertranscnt := 0
+	var counted uint32 // The blocks below it are in usertranscnt
+	var cerr error
+	countTo := func(h uint32) {
+		for ; counted < h && cerr == nil; counted++ {
+			fb, err := GetFactoidBlock(counted)
+			if err != nil {
+				cerr = err
+			} else if fb == nil {
+				cerr = fmt.Errorf("Factoid block at height %d is missing from the database", counted)
+			} else {
+				usertranscnt += len(fb.GetTransactions()) - 1
+			}
+		}
+	}
+	skipped := func(from, to uint32) {
+		if from == to {
+			ret.WriteString(fmt.Sprintf("Skipped block %d\n\n", from))
+		} else if from < to {
+			ret.WriteString(fmt.Sprintf("Skipped blocks %d-%d\n\n", from, to))
+		}
+	}
+	// Blocks where only the coinbase pays the addresses are skipped.
+	flush := func() {
+		if shown {
+			if height > next {
+				skipped(next, height-1)
+			}
+			ret.WriteString(out.String())
+			next = height + 1
+		}
+		out.Reset()
+		shown = false
+	}
+
+	top, err := eachAddressTransaction(addresses, func(h uint32, j uint32, fb interfaces.IFBlock, t interfaces.ITransaction) {
+		if cerr != nil {
+			return
+		}
+		if out.Len() == 0 || h != height {
+			flush()
+			height = h
+			out.WriteString(fmt.Sprintf("Block Height %d total transactions %d\n", h, len(fb.GetTransactions())))
+		}
+		if j == 0 && len(t.GetOutputs()) == 0 {
+			out.WriteString("\nEmpty Coinbase Transaction\n\n")
+		} else if j == 0 {
+			out.WriteString("\nCoinbase Transaction\n")
+			out.WriteString(fmt.Sprintf("%s\n", t.String()))
+		} else {
+			countTo(h)
+			out.WriteString(fmt.Sprintf("Transaction %d Block Height %d\n", usertranscnt+int(j), h))
+			out.WriteString(fmt.Sprintf("%s\n", t.String()))
+			shown = true
+		}
+	})
+	if err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+	flush()
+	if next <= top {
+		skipped(next, top)
+	}
+	return ret.Bytes(), nil
 }
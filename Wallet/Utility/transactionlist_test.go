@@ -0,0 +1,279 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package Utility_test
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/FactomProject/ed25519"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/fctwallet2/Wallet/Node"
+	"github.com/FactomProject/fctwallet2/Wallet/Utility"
+	"github.com/FactomProject/fctwallet2/scwallet"
+)
+
+// countingNode counts the Factoid blocks read from the node.
+type countingNode struct {
+	Node.Client
+	reads int
+}
+
+func (c *countingNode) FactoidBlock(ctx context.Context, keymr string) (interfaces.IFBlock, error) {
+	c.reads++
+	return c.Client.FactoidBlock(ctx, keymr)
+}
+
+// newStore keeps synced blocks in an empty database, until the cleanup is
+// called.
+func newStore(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "fctwallet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	Utility.SetStore(scwallet.NewSCWallet(dir+"/", "test_wallet.db").GetDB())
+	return func() { os.RemoveAll(dir) }
+}
+
+// produceBlocks funds the address, if one is given, in the first of n new
+// blocks.
+func produceBlocks(t *testing.T, m *Node.Mock, adr string, n int) {
+	if adr != "" {
+		if err := m.Fund(adr, 100); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < n; i++ {
+		if _, err := m.ProduceBlock(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSync(t *testing.T) {
+	ctx := context.Background()
+	defer newStore(t)()
+
+	adr := "dceb1ce5778444e7777172e1f586488d2382fb1037887cd79a70b0cba4fb3dce"
+	mock := Node.NewMock(1000)
+	produceBlocks(t, mock, adr, 3)
+	node := &countingNode{Client: mock}
+	Utility.SetNode(node)
+
+	adrBytes, _ := hex.DecodeString(adr)
+	if _, err := Utility.HasTransactions(adrBytes); err != Utility.ErrNotSynced {
+		t.Errorf("Expected not yet synced, got %v", err)
+	}
+	if err := Utility.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if h, err := Utility.GetDBHeight(); err != nil || h != 2 {
+		t.Fatalf("Synced to height %d, expected 2: %v", h, err)
+	}
+	if node.reads != 3 {
+		t.Errorf("Read %d Factoid blocks, expected 3", node.reads)
+	}
+	head, _ := mock.DirectoryBlockHead(ctx)
+	cursor, err := Utility.GetCursor()
+	if err != nil || cursor == nil || hex.EncodeToString(cursor.KeyMR) != head {
+		t.Fatalf("The cursor is not at the head: %v", err)
+	}
+
+	// Only the blocks added since are read.
+	produceBlocks(t, mock, "", 1)
+	if err := Utility.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if node.reads != 4 {
+		t.Errorf("Read %d Factoid blocks, expected 4", node.reads)
+	}
+	if s := Utility.GetSyncStatus(); s.State != Utility.SyncStateSynced || s.Height != 3 {
+		t.Errorf("Sync is %s at height %d, expected synced at 3", s.State, s.Height)
+	}
+
+	// A failed sync is reported, and the blocks synced are still read from
+	// the database.
+	mock.SetDown(true)
+	if err := Utility.Sync(ctx); err == nil {
+		t.Error("Synced from a node that is down")
+	}
+	if s := Utility.GetSyncStatus(); s.State != Utility.SyncStateError || s.LastError != Node.ErrDown.Error() {
+		t.Errorf("Sync is %s with error %q, expected the node to be down", s.State, s.LastError)
+	}
+	if found, err := Utility.HasTransactions(adrBytes); err != nil || !found {
+		t.Errorf("The funding is not in the synced blocks: %v", err)
+	}
+}
+
+// synced checks the synced blocks are the ones the node has.
+func synced(t *testing.T, m *Node.Mock) {
+	ctx := context.Background()
+	if h, err := Utility.GetDBHeight(); err != nil || int(h) != m.Height() {
+		t.Fatalf("Synced to height %d, expected %d: %v", h, m.Height(), err)
+	}
+	keymr, _ := m.DirectoryBlockHead(ctx)
+	for h := m.Height(); h >= 0; h-- {
+		db, err := Utility.GetDirectoryBlock(uint32(h))
+		if err != nil || db == nil {
+			t.Fatalf("Missing block %d: %v", h, err)
+		}
+		if db.GetKeyMR().String() != keymr {
+			t.Fatalf("Block %d is not the one the node has", h)
+		}
+		keymr = db.GetHeader().GetPrevKeyMR().String()
+	}
+}
+
+func TestSyncReorg(t *testing.T) {
+	ctx := context.Background()
+	defer newStore(t)()
+
+	alice := "dceb1ce5778444e7777172e1f586488d2382fb1037887cd79a70b0cba4fb3dce"
+	bob := "9881aeb264452a4f7fafa1cc7bc4b93a05c55537c0703453e585f6d83ce77dca"
+	carol := "3a8e47fd2e8e7ef4e0de2ad4e2b9d1bd6c1df4b9e5fdf1bc6d2e0d1b3c2a1f0e"
+	has := func(adr string) bool {
+		b, _ := hex.DecodeString(adr)
+		found, err := Utility.HasTransactions(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return found
+	}
+
+	mock := Node.NewMock(1000)
+	produceBlocks(t, mock, alice, 3)
+	Utility.SetNode(mock)
+	if err := Utility.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	synced(t, mock)
+
+	// A longer fork from the first block replaces the two after it.
+	if err := mock.Fork(0); err != nil {
+		t.Fatal(err)
+	}
+	produceBlocks(t, mock, bob, 3)
+	if err := Utility.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	synced(t, mock)
+	if !has(alice) || !has(bob) {
+		t.Error("Lost a payment in the blocks before or after the fork")
+	}
+
+	// So does a shorter one, dropping bob's payment.
+	if err := mock.Fork(0); err != nil {
+		t.Fatal(err)
+	}
+	produceBlocks(t, mock, carol, 1)
+	if err := Utility.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	synced(t, mock)
+	if !has(carol) || has(bob) {
+		t.Error("The blocks synced are not the ones of the fork")
+	}
+
+	// A fork from before the first block replaces every block.
+	if err := mock.Fork(-1); err != nil {
+		t.Fatal(err)
+	}
+	produceBlocks(t, mock, bob, 2)
+	if err := Utility.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	synced(t, mock)
+	if has(alice) || !has(bob) {
+		t.Error("The blocks synced are not the ones of the fork")
+	}
+}
+
+func TestHistory(t *testing.T) {
+	defer newStore(t)()
+
+	alice := "dceb1ce5778444e7777172e1f586488d2382fb1037887cd79a70b0cba4fb3dce"
+	bob := "9881aeb264452a4f7fafa1cc7bc4b93a05c55537c0703453e585f6d83ce77dca"
+	mock := Node.NewMock(1000)
+	produceBlocks(t, mock, alice, 1)
+	produceBlocks(t, mock, bob, 1)
+	if err := mock.Fund(alice, 100); err != nil {
+		t.Fatal(err)
+	}
+	produceBlocks(t, mock, bob, 1)
+	Utility.SetNode(mock)
+	if err := Utility.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	a, _ := hex.DecodeString(alice)
+	b, _ := hex.DecodeString(bob)
+	filter := &Utility.HistoryFilter{Addresses: [][]byte{a, b}}
+
+	// Newest first, with a coinbase paying both only listed once.
+	var heights []uint32
+	var amounts []uint64
+	after := ""
+	for pages := 0; ; pages++ {
+		page, err := Utility.History(filter, after, 2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, tx := range page.Transactions {
+			if tx.Direction != Utility.HistoryIn {
+				t.Errorf("A coinbase payment is %s, expected in", tx.Direction)
+			}
+			heights = append(heights, tx.Height)
+			amounts = append(amounts, tx.Amount)
+		}
+		if page.Next == "" {
+			if pages != 1 {
+				t.Errorf("Read %d pages, expected 2", pages+1)
+			}
+			break
+		}
+		after = page.Next
+	}
+	if len(heights) != 3 || heights[0] != 2 || heights[1] != 1 || heights[2] != 0 || amounts[0] != 200 {
+		t.Errorf("Expected the blocks newest first: %v %v", heights, amounts)
+	}
+
+	filter.FromHeight = 1
+	filter.MinAmount = 150
+	if page, err := Utility.History(filter, "", 0); err != nil || len(page.Transactions) != 1 || page.Transactions[0].Height != 2 {
+		t.Errorf("Expected only the payment to both: %+v %v", page, err)
+	}
+	filter.Directions = []string{Utility.HistoryOut}
+	if page, err := Utility.History(filter, "", 0); err != nil || len(page.Transactions) != 0 {
+		t.Errorf("Expected no payments out: %+v %v", page, err)
+	}
+	if _, err := Utility.History(filter, "bad", 0); err == nil {
+		t.Error("Accepted a bad cursor")
+	}
+
+	// Commits are in entry credits, so the minimum amount doesn't apply.
+	pub, pri, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := make([]byte, 40)
+	msg[39] = 1
+	mock.FundEC(hex.EncodeToString(pub[:]), 1)
+	if err := mock.CommitEntry(context.Background(), append(append(msg, pub[:]...), ed25519.Sign(pri, msg)[:]...)); err != nil {
+		t.Fatal(err)
+	}
+	produceBlocks(t, mock, "", 1)
+	if err := Utility.Sync(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	filter = &Utility.HistoryFilter{Addresses: [][]byte{pub[:]}, MinAmount: 150}
+	if page, err := Utility.History(filter, "", 0); err != nil || len(page.Transactions) != 1 ||
+		page.Transactions[0].Direction != Utility.HistoryCommit {
+		t.Errorf("Expected the commit: %+v %v", page, err)
+	}
+}
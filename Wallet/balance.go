@@ -5,12 +5,9 @@
 package Wallet
 
 import (
+	"context"
 	"encoding/hex"
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"net/http"
-	"strconv"
 	"strings"
 
 	"github.com/FactomProject/factomd/common/primitives"
@@ -52,71 +49,87 @@ func LookupAddress(adrType string, adr string) (string, error) {
 	return adr, nil
 }
 
-func FactoidBalance(adr string) (int64, error) {
+func FactoidBalance(ctx context.Context, adr string) (int64, error) {
 	adr, err := LookupAddress("FA", adr)
 	if err != nil {
 		return 0, err
 	}
 
-	str := fmt.Sprintf("http://%s:%d/v1/factoid-balance/%s", ipaddressFD, portNumberFD, adr)
-	resp, err := http.Get(str)
-	if err != nil {
-		return 0, err
-	}
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
+	if err := checkOnline(); err != nil {
 		return 0, err
 	}
-	resp.Body.Close()
 
-	b := new(Response)
-	if err := json.Unmarshal(body, b); err != nil {
-		return 0, err
-	}
+	return node.FactoidBalance(ctx, adr)
+}
 
-	if !b.Success {
-		return 0, fmt.Errorf("%s", b.Response)
+func ECBalance(ctx context.Context, adr string) (int64, error) {
+	adr, err := LookupAddress("EC", adr)
+	if err != nil {
+		return 0, err
 	}
 
-	v, err := strconv.ParseInt(b.Response, 10, 64)
-	if err != nil {
+	if err := checkOnline(); err != nil {
 		return 0, err
 	}
 
-	return v, nil
+	return node.ECBalance(ctx, adr)
+}
 
+// LocalFactoidBalance returns the balance of the Factoid address computed
+// from the synced blocks, without asking factomd.
+func LocalFactoidBalance(adr string) (int64, error) {
+	return localBalance("FA", adr)
 }
 
-func ECBalance(adr string) (int64, error) {
-	adr, err := LookupAddress("EC", adr)
-	if err != nil {
-		return 0, err
-	}
+// LocalECBalance returns the balance of the entry credit address computed
+// from the synced blocks, without asking factomd.
+func LocalECBalance(adr string) (int64, error) {
+	return localBalance("EC", adr)
+}
 
-	str := fmt.Sprintf("http://%s:%d/v1/entry-credit-balance/%s", ipaddressFD, portNumberFD, adr)
-	resp, err := http.Get(str)
+func localBalance(adrType string, adr string) (int64, error) {
+	adr, err := LookupAddress(adrType, adr)
 	if err != nil {
 		return 0, err
 	}
-	body, err := ioutil.ReadAll(resp.Body)
+	b, err := hex.DecodeString(adr)
 	if err != nil {
 		return 0, err
 	}
-	resp.Body.Close()
+	return Utility.Balance(b)
+}
 
-	b := new(Response)
-	if err := json.Unmarshal(body, b); err != nil {
-		return 0, err
-	}
+// A BalanceCheck is the balance of an address computed from the synced
+// blocks, next to the one factomd reports.  They differ while factomd has
+// transactions or commits waiting for the next block, or the wallet is
+// behind, as well as when the synced blocks are wrong.
+type BalanceCheck struct {
+	Local int64
+	Node  int64
+}
 
-	if !b.Success {
-		return 0, fmt.Errorf("%s", b.Response)
-	}
+func (c *BalanceCheck) Matches() bool {
+	return c.Local == c.Node
+}
 
-	v, err := strconv.ParseInt(b.Response, 10, 64)
+// CheckBalance returns the balance of the FA or EC address computed from
+// the synced blocks, and the one factomd reports.
+func CheckBalance(ctx context.Context, adrType string, adr string) (*BalanceCheck, error) {
+	c := new(BalanceCheck)
+	var err error
+	if adrType == "EC" {
+		if c.Local, err = LocalECBalance(adr); err != nil {
+			return nil, err
+		}
+		c.Node, err = ECBalance(ctx, adr)
+	} else {
+		if c.Local, err = LocalFactoidBalance(adr); err != nil {
+			return nil, err
+		}
+		c.Node, err = FactoidBalance(ctx, adr)
+	}
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
-
-	return v, nil
+	return c, nil
 }
@@ -0,0 +1,196 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package Wallet
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// Transactions count their inputs and outputs in a byte, and must fit in
+// 10KB.  Keeping to 200 outputs stays well clear of both.
+const MaxBatchOutputs = 200
+
+// A BatchRow is one payment of a batch, and what became of it.
+type BatchRow struct {
+	Address string // FA or EC address
+	Amount  uint64 // factoshis
+	TxID    string
+	Error   string
+}
+
+// A BatchResult sums up a batch of payments.  For a dry run, nothing is
+// signed or submitted and TxIDs are empty.
+type BatchResult struct {
+	Rows         []BatchRow
+	Transactions []string
+	Total        uint64 // paid to the rows
+	Fees         uint64
+	Balance      uint64 // of the source address
+	DryRun       bool
+}
+
+// PayBatch pays every row from the source address, the name or FA address
+// of an address in the wallet.  Rows are split over as many transactions as
+// needed to keep each under MaxBatchOutputs.  If any row has an invalid
+// address or one paid by an earlier row, or the source can't cover the
+// batch, nothing is paid.
+func PayBatch(ctx context.Context, source string, rows []BatchRow, dryRun bool) (*BatchResult, error) {
+	if err := checkOnline(); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("No payments given")
+	}
+
+	result := &BatchResult{Rows: rows, DryRun: dryRun}
+	valid := true
+	// Each address is paid once, so each row is one output.
+	paid := make(map[string]int)
+	for i := range rows {
+		rows[i].Address = strings.TrimSpace(rows[i].Address)
+		first, dup := paid[rows[i].Address]
+		switch {
+		case !primitives.ValidateFUserStr(rows[i].Address) && !primitives.ValidateECUserStr(rows[i].Address):
+			rows[i].Error = "Invalid address"
+		case rows[i].Amount == 0:
+			rows[i].Error = "Cannot send an amount of zero"
+		case dup:
+			rows[i].Error = fmt.Sprintf("Address is already paid by row %d", first+1)
+		default:
+			paid[rows[i].Address] = i
+			result.Total += rows[i].Amount
+			continue
+		}
+		valid = false
+	}
+	if !valid {
+		return result, fmt.Errorf("Some rows are invalid. Nothing was paid")
+	}
+
+	adr, err := LookupAddress("FA", source)
+	if err != nil {
+		return nil, err
+	}
+	data, err := hex.DecodeString(adr)
+	if err != nil {
+		return nil, err
+	}
+	from := factoid.NewAddress(data)
+	if _, err := wallet.GetAddressHash(from); err != nil {
+		return nil, fmt.Errorf("%s is not an address in the wallet", source)
+	}
+	balance, err := FactoidBalance(ctx, adr)
+	if err != nil {
+		return nil, err
+	}
+	result.Balance = uint64(balance)
+	rate, err := GetFee(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var batches []interfaces.ITransaction
+	for start := 0; start < len(rows); start += MaxBatchOutputs {
+		end := start + MaxBatchOutputs
+		if end > len(rows) {
+			end = len(rows)
+		}
+		trans, fee, err := composeBatch(from, rows[start:end], uint64(rate))
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, trans)
+		result.Fees += fee
+	}
+
+	if result.Total+result.Fees > result.Balance {
+		return result, fmt.Errorf("The batch needs %s but %s has %s",
+			primitives.ConvertDecimalToString(result.Total+result.Fees), source,
+			primitives.ConvertDecimalToString(result.Balance))
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	for b, trans := range batches {
+		start := b * MaxBatchOutputs
+		end := start + len(trans.GetOutputs()) + len(trans.GetECOutputs())
+		err := payBatch(ctx, trans)
+		for i := start; i < end; i++ {
+			if err != nil {
+				rows[i].Error = err.Error()
+			} else {
+				rows[i].TxID = trans.GetSigHash().String()
+			}
+		}
+		if err != nil {
+			// Later transactions are not sent, so the batch can be rerun
+			// from here.
+			for i := end; i < len(rows); i++ {
+				rows[i].Error = "Not sent"
+			}
+			return result, err
+		}
+		result.Transactions = append(result.Transactions, trans.GetSigHash().String())
+	}
+	return result, nil
+}
+
+// composeBatch builds one transaction paying the rows from the address,
+// which pays the fee as well.
+func composeBatch(from interfaces.IAddress, rows []BatchRow, rate uint64) (interfaces.ITransaction, uint64, error) {
+	var total uint64
+	for _, row := range rows {
+		total += row.Amount
+	}
+
+	// The fee depends on the size of the input amount, which includes the
+	// fee.  It settles after a round or two.
+	var fee uint64
+	for {
+		trans := wallet.CreateTransaction(interfaces.GetTimeMilli())
+		if err := wallet.AddInput(trans, from, total+fee); err != nil {
+			return nil, 0, err
+		}
+		for _, row := range rows {
+			to := factoid.NewAddress(primitives.ConvertUserStrToAddress(row.Address))
+			var err error
+			if primitives.ValidateECUserStr(row.Address) {
+				err = wallet.AddECOutput(trans, to, row.Amount)
+			} else {
+				err = wallet.AddOutput(trans, to, row.Amount)
+			}
+			if err != nil {
+				return nil, 0, err
+			}
+		}
+		f, err := trans.CalculateFee(rate)
+		if err != nil {
+			return nil, 0, err
+		}
+		if f == fee {
+			return trans, fee, nil
+		}
+		fee = f
+	}
+}
+
+func payBatch(ctx context.Context, trans interfaces.ITransaction) error {
+	signed, err := wallet.SignInputs(trans)
+	if err != nil {
+		return err
+	}
+	if !signed {
+		return fmt.Errorf("Do not have all the private keys required to sign this transaction")
+	}
+	return submitTransaction(ctx, trans)
+}
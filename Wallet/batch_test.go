@@ -0,0 +1,180 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package Wallet
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/fctwallet2/Wallet/Node"
+)
+
+// failingNode rejects the nth transaction submitted to it.
+type failingNode struct {
+	*Node.Mock
+	submits int
+	failAt  int
+}
+
+func (n *failingNode) FactoidSubmit(ctx context.Context, data []byte) error {
+	n.submits++
+	if n.submits == n.failAt {
+		return Node.Rejection("Rejected for the test")
+	}
+	return n.Mock.FactoidSubmit(ctx, data)
+}
+
+// batchRows pays a different address a different amount each row.  Every
+// third row buys entry credits.
+func batchRows(n int) []BatchRow {
+	rows := make([]BatchRow, n)
+	for i := range rows {
+		data := make([]byte, 32)
+		binary.BigEndian.PutUint32(data, uint32(i))
+		adr := factoid.NewAddress(data)
+		rows[i] = BatchRow{Address: primitives.ConvertFctAddressToUserStr(adr), Amount: uint64(10000 + i)}
+		if i%3 == 2 {
+			rows[i].Address = primitives.ConvertECAddressToUserStr(adr)
+		}
+	}
+	return rows
+}
+
+// paysRow is true if the transaction has an output for the row.
+func paysRow(trans interfaces.ITransaction, row BatchRow) bool {
+	adr := primitives.ConvertUserStrToAddress(row.Address)
+	outputs := trans.GetOutputs()
+	if primitives.ValidateECUserStr(row.Address) {
+		outputs = trans.GetECOutputs()
+	}
+	for _, out := range outputs {
+		if bytes.Equal(out.GetAddress().Bytes(), adr) && out.GetAmount() == row.Amount {
+			return true
+		}
+	}
+	return false
+}
+
+func TestPayBatch(t *testing.T) {
+	ctx := context.Background()
+	mock, cleanup := newTestWallet(t)
+	defer cleanup()
+
+	if _, err := GenerateAddress("source"); err != nil {
+		t.Fatal(err)
+	}
+	source, err := LookupAddress("FA", "source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.Fund(source, 1000000000); err != nil {
+		t.Fatal(err)
+	}
+
+	// The transactions have both kinds of output.
+	rows := batchRows(2*MaxBatchOutputs + 50)
+	dry, err := PayBatch(ctx, "source", batchRows(len(rows)), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mock.Pending()) != 0 || len(dry.Transactions) != 0 {
+		t.Fatal("A dry run submitted transactions")
+	}
+
+	result, err := PayBatch(ctx, "source", rows, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Fees != dry.Fees || result.Total != dry.Total {
+		t.Errorf("The dry run worked out %d and %d, the batch paid %d and %d",
+			dry.Total, dry.Fees, result.Total, result.Fees)
+	}
+	pending := mock.Pending()
+	if len(pending) != 3 || len(result.Transactions) != 3 {
+		t.Fatalf("Split %d rows into %d transactions, expected 3", len(rows), len(pending))
+	}
+	txs := make(map[string]interfaces.ITransaction)
+	for i, trans := range pending {
+		outputs := len(trans.GetOutputs()) + len(trans.GetECOutputs())
+		if (i < 2 && outputs != MaxBatchOutputs) || (i == 2 && outputs != 50) {
+			t.Errorf("Transaction %d has %d outputs", i, outputs)
+		}
+		txs[trans.GetSigHash().String()] = trans
+	}
+	for i, row := range rows {
+		trans := txs[row.TxID]
+		if row.Error != "" || trans == nil {
+			t.Fatalf("Row %d was not paid: %+v", i, row)
+		}
+		if row.TxID != result.Transactions[i/MaxBatchOutputs] || !paysRow(trans, row) {
+			t.Errorf("Row %d is mapped to the wrong transaction", i)
+		}
+	}
+
+	// The second transaction fails. The first stays paid, the last isn't
+	// sent.
+	SetNode(&failingNode{Mock: mock, failAt: 2})
+	rows = batchRows(2*MaxBatchOutputs + 50)
+	result, err = PayBatch(ctx, "source", rows, false)
+	if err == nil {
+		t.Fatal("The failed transaction was not reported")
+	}
+	if len(result.Transactions) != 1 || len(mock.Pending()) != 4 {
+		t.Fatalf("Sent %d transactions of the batch, expected 1", len(result.Transactions))
+	}
+	for i, row := range rows {
+		switch {
+		case i < MaxBatchOutputs:
+			if row.TxID != result.Transactions[0] || row.Error != "" {
+				t.Errorf("Row %d should have been paid: %+v", i, row)
+			}
+		case i < 2*MaxBatchOutputs:
+			if row.TxID != "" || row.Error != err.Error() {
+				t.Errorf("Row %d should have the error: %+v", i, row)
+			}
+		default:
+			if row.TxID != "" || row.Error != "Not sent" {
+				t.Errorf("Row %d should not have been sent: %+v", i, row)
+			}
+		}
+	}
+}
+
+func TestPayBatchDuplicate(t *testing.T) {
+	ctx := context.Background()
+	mock, cleanup := newTestWallet(t)
+	defer cleanup()
+
+	if _, err := GenerateAddress("source"); err != nil {
+		t.Fatal(err)
+	}
+	source, err := LookupAddress("FA", "source")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.Fund(source, 1000000000); err != nil {
+		t.Fatal(err)
+	}
+
+	rows := batchRows(3)
+	rows = append(rows, BatchRow{Address: " " + rows[1].Address, Amount: 5000})
+	result, err := PayBatch(ctx, "source", rows, false)
+	if err == nil {
+		t.Fatal("Paid the same address twice")
+	}
+	if len(mock.Pending()) != 0 {
+		t.Error("A batch with a duplicate row was sent")
+	}
+	for i, row := range result.Rows {
+		if (i == 3) != (row.Error != "") {
+			t.Errorf("Row %d: %+v", i, row)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package Wallet
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// How long BuyEntryCredits waits to see the entry credits arrive, when
+// asked to, and how often it looks.
+var (
+	ECConfirmTimeout  = 2 * time.Minute
+	ECConfirmInterval = 5 * time.Second
+)
+
+// An ECPurchase is the result of buying entry credits.
+type ECPurchase struct {
+	TxID      string
+	Factoshis uint64 // paid for the entry credits
+	Fee       uint64
+	Rate      uint64 // factoshis per entry credit
+	Balance   int64  // of the entry credit address
+	Confirmed bool   // if the entry credits showed up in the balance
+	Waited    bool   // for the entry credits to show up
+}
+
+// GetECRate returns the number of factoshis an entry credit costs, as
+// factomd has it now.  Fees are priced in entry credits, so this is the
+// same rate the fee is worked out with.
+func GetECRate(ctx context.Context) (uint64, error) {
+	rate, err := GetFee(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if rate <= 0 {
+		return 0, fmt.Errorf("factomd gave an invalid entry credit rate of %d", rate)
+	}
+	wallet.SetECRate(uint64(rate))
+	return uint64(rate), nil
+}
+
+// BuyEntryCredits buys count entry credits for the entry credit address at
+// the current rate, paid for with the fee from the source address, and
+// reports the balance of the entry credit address.  With wait, it waits up
+// to ECConfirmTimeout for the entry credits to show up.  Without, it returns
+// once the transaction is submitted, and they may not be in the balance yet.
+func BuyEntryCredits(ctx context.Context, source string, ecAddress string, count uint64, wait bool) (*ECPurchase, error) {
+	if err := checkOnline(); err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, fmt.Errorf("Cannot buy zero entry credits")
+	}
+
+	ec, err := LookupAddress("EC", ecAddress)
+	if err != nil {
+		return nil, err
+	}
+	ecdata, err := hex.DecodeString(ec)
+	if err != nil {
+		return nil, err
+	}
+	adr, err := LookupAddress("FA", source)
+	if err != nil {
+		return nil, err
+	}
+	data, err := hex.DecodeString(adr)
+	if err != nil {
+		return nil, err
+	}
+	from := factoid.NewAddress(data)
+	if _, err := wallet.GetAddressHash(from); err != nil {
+		return nil, fmt.Errorf("%s is not an address in the wallet", source)
+	}
+
+	rate, err := GetECRate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	purchase := &ECPurchase{Rate: rate, Factoshis: count * rate}
+	row := BatchRow{
+		Address: primitives.ConvertECAddressToUserStr(factoid.NewAddress(ecdata)),
+		Amount:  purchase.Factoshis,
+	}
+	trans, fee, err := composeBatch(from, []BatchRow{row}, rate)
+	if err != nil {
+		return nil, err
+	}
+	purchase.Fee = fee
+
+	balance, err := FactoidBalance(ctx, adr)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(balance) < purchase.Factoshis+fee {
+		return nil, fmt.Errorf("%d entry credits cost %s plus a fee of %s, but %s has %s", count,
+			primitives.ConvertDecimalToString(purchase.Factoshis), primitives.ConvertDecimalToString(fee),
+			source, primitives.ConvertDecimalToString(uint64(balance)))
+	}
+
+	before, err := ECBalance(ctx, ec)
+	if err != nil {
+		return nil, err
+	}
+	if err := payBatch(ctx, trans); err != nil {
+		return nil, err
+	}
+	purchase.TxID = trans.GetSigHash().String()
+	purchase.Waited = wait
+
+	deadline := time.Now().Add(ECConfirmTimeout)
+	for {
+		purchase.Balance, err = ECBalance(ctx, ec)
+		if err != nil {
+			return purchase, err
+		}
+		if purchase.Balance >= before+int64(count) {
+			purchase.Confirmed = true
+			return purchase, nil
+		}
+		if !wait || time.Now().After(deadline) {
+			return purchase, nil
+		}
+		select {
+		case <-ctx.Done():
+			return purchase, ctx.Err()
+		case <-time.After(ECConfirmInterval):
+		}
+	}
+}
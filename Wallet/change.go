@@ -0,0 +1,39 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package Wallet
+
+import (
+	"fmt"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/fctwallet2/Wallet/Utility"
+	"github.com/FactomProject/fctwallet2/scwallet"
+)
+
+// SetChangeAddress sends the change of the transactions the wallet funds to
+// the named address.  An empty name generates a fresh change address for
+// each transaction instead.
+func SetChangeAddress(name string) error {
+	if len(name) > 0 && Utility.IsValidKey(name) == false {
+		return fmt.Errorf("Invalid name or address")
+	}
+	return wallet.SetChangeAddress([]byte(name))
+}
+
+// GetChangeAddress returns the name of the change address, or "" if a fresh
+// one is generated for each transaction.
+func GetChangeAddress() (string, error) {
+	name, err := wallet.GetChangeAddress()
+	if err != nil {
+		return "", err
+	}
+	return string(name), nil
+}
+
+// IsChange is true for an address the wallet generated to take change.
+func IsChange(we interfaces.IWalletEntry) bool {
+	e, ok := we.(*scwallet.WalletEntry)
+	return ok && e.IsChange()
+}
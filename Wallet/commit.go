@@ -5,11 +5,10 @@
 package Wallet
 
 import (
-	"bytes"
+	"context"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"strings"
 
 	"github.com/FactomProject/factomd/common/interfaces"
@@ -17,15 +16,11 @@ import (
 	"github.com/FactomProject/fctwallet2/Wallet/Utility"
 )
 
-func CommitChain(name string, data []byte) error {
+func CommitChain(ctx context.Context, name string, data []byte) error {
 	type walletcommit struct {
 		Message string
 	}
 
-	type commit struct {
-		CommitChainMsg string
-	}
-
 	in := new(walletcommit)
 	json.Unmarshal(data, in)
 	msg, err := hex.DecodeString(in.Message)
@@ -60,36 +55,23 @@ func CommitChain(name string, data []byte) error {
 		return fmt.Errorf("Unknown address")
 	}
 
-	signed := wallet.SignCommit(we, msg)
-
-	com := new(commit)
-	com.CommitChainMsg = hex.EncodeToString(signed)
-	j, err := json.Marshal(com)
+	signed, err := wallet.SignCommit(we, msg)
 	if err != nil {
-		return fmt.Errorf("Could not create json post:", err)
+		return err
 	}
 
-	resp, err := http.Post(
-		fmt.Sprintf("http://%s:%d/v1/commit-chain", ipaddressFD, portNumberFD),
-		"application/json",
-		bytes.NewBuffer(j))
-	if err != nil {
-		return fmt.Errorf("Could not post to server:", err)
+	if err := checkOnline(); err != nil {
+		return err
 	}
-	resp.Body.Close()
 
-	return nil
+	return node.CommitChain(ctx, signed)
 }
 
-func CommitEntry(name string, data []byte) error {
+func CommitEntry(ctx context.Context, name string, data []byte) error {
 	type walletcommit struct {
 		Message string
 	}
 
-	type commit struct {
-		CommitEntryMsg string
-	}
-
 	in := new(walletcommit)
 	json.Unmarshal(data, in)
 	msg, err := hex.DecodeString(in.Message)
@@ -101,22 +83,14 @@ func CommitEntry(name string, data []byte) error {
 	if err != nil {
 		return err
 	}
-	signed := wallet.SignCommit(we.(interfaces.IWalletEntry), msg)
-
-	com := new(commit)
-	com.CommitEntryMsg = hex.EncodeToString(signed)
-	j, err := json.Marshal(com)
+	signed, err := wallet.SignCommit(we.(interfaces.IWalletEntry), msg)
 	if err != nil {
-		return fmt.Errorf("Could not create json post:", err)
+		return err
 	}
 
-	resp, err := http.Post(
-		fmt.Sprintf("http://%s:%d/v1/commit-entry/", ipaddressFD, portNumberFD),
-		"application/json",
-		bytes.NewBuffer(j))
-	if err != nil {
-		return fmt.Errorf("Could not post to server:", err)
+	if err := checkOnline(); err != nil {
+		return err
 	}
-	resp.Body.Close()
-	return nil
+
+	return node.CommitEntry(ctx, signed)
 }
@@ -31,10 +31,15 @@ func ComposeChainSubmit(name string, data []byte) ([]byte, error) {
 		return nil, err
 	}
 
+	key, err := wallet.GetPrivateKey(we, 0)
+	if err != nil {
+		return nil, err
+	}
+
 	pub := new([constants.ADDRESS_LENGTH]byte)
 	copy(pub[:], we.(interfaces.IWalletEntry).GetKey(0))
 	pri := new([constants.PRIVATE_LENGTH]byte)
-	copy(pri[:], we.(interfaces.IWalletEntry).GetPrivKey(0))
+	copy(pri[:], key)
 
 	sub.ChainID = c.ChainID
 
@@ -70,10 +75,15 @@ func ComposeEntrySubmit(name string, data []byte) ([]byte, error) {
 		return nil, err
 	}
 
+	key, err := wallet.GetPrivateKey(we, 0)
+	if err != nil {
+		return nil, err
+	}
+
 	pub := new([constants.ADDRESS_LENGTH]byte)
 	copy(pub[:], we.(interfaces.IWalletEntry).GetKey(0))
 	pri := new([constants.PRIVATE_LENGTH]byte)
-	copy(pri[:], we.(interfaces.IWalletEntry).GetPrivKey(0))
+	copy(pri[:], key)
 
 	if j, err := factom.ComposeEntryCommit(pub, pri, e); err != nil {
 		return nil, err
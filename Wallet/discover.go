@@ -0,0 +1,138 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package Wallet
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/fctwallet2/Wallet/Utility"
+)
+
+// How many unused addresses in a row end the search, if not specified
+const DefaultGapLimit = 20
+
+// DiscoverAddresses walks the addresses derived from the root seed, Factoid
+// and Entry Credit separately, until it finds gap consecutive addresses with
+// no balance and no transactions.  Used addresses the wallet doesn't have yet
+// are imported under generated names, and returned.  The blocks are synced
+// first, so the latest transactions are seen.
+func DiscoverAddresses(ctx context.Context, gap int) ([]string, error) {
+	if gap == 0 {
+		gap = DefaultGapLimit
+	}
+	if gap < 0 {
+		return nil, fmt.Errorf("Invalid gap limit %d", gap)
+	}
+	if err := Utility.Sync(ctx); err != nil {
+		return nil, err
+	}
+
+	fct, err := discover(ctx, "fct", gap)
+	if err != nil {
+		return fct, err
+	}
+	ec, err := discover(ctx, "ec", gap)
+	return append(fct, ec...), err
+}
+
+func discover(ctx context.Context, addrtype string, gap int) ([]string, error) {
+	var found []string
+	unused := 0
+	for index := uint32(0); unused < gap; index++ {
+		pub, _, _, err := wallet.DeriveKey(addrtype, index)
+		if err != nil {
+			return found, err
+		}
+		used, err := addressUsed(ctx, addrtype, pub)
+		if err != nil {
+			return found, err
+		}
+		if !used {
+			unused++
+			continue
+		}
+		unused = 0
+
+		we, err := wallet.GetDB().FetchWalletEntryByPublicKey(pub)
+		if err != nil {
+			return found, err
+		}
+		if we != nil {
+			continue // Already in the wallet
+		}
+
+		var name, adr string
+		if addrtype == "fct" {
+			name = fmt.Sprintf("restored-fa-%d", index)
+		} else {
+			name = fmt.Sprintf("restored-ec-%d", index)
+		}
+		name, err = freeName(name)
+		if err != nil {
+			return found, err
+		}
+		addr, err := wallet.AddDerivedAddress(addrtype, []byte(name), index)
+		if err != nil {
+			return found, err
+		}
+		if addrtype == "fct" {
+			adr = primitives.ConvertFctAddressToUserStr(addr)
+		} else {
+			adr = primitives.ConvertECAddressToUserStr(addr)
+		}
+		found = append(found, adr)
+	}
+	return found, nil
+}
+
+// freeName returns the name, or if an address already has it, the name
+// with the first number after it that no address has.
+func freeName(name string) (string, error) {
+	for n := 1; ; n++ {
+		candidate := name
+		if n > 1 {
+			candidate = fmt.Sprintf("%s-%d", name, n)
+		}
+		we, err := wallet.GetDB().FetchWalletEntryByName([]byte(candidate))
+		if err != nil {
+			return "", err
+		}
+		if we == nil {
+			return candidate, nil
+		}
+	}
+}
+
+// addressUsed is true if the address for the public key has a balance or
+// has ever been part of a transaction.
+func addressUsed(ctx context.Context, addrtype string, pub []byte) (bool, error) {
+	adr := pub
+	if addrtype == "fct" {
+		a, err := factoid.NewRCD_1(pub).GetAddress()
+		if err != nil {
+			return false, err
+		}
+		adr = a.Bytes()
+	}
+
+	var bal int64
+	var err error
+	if addrtype == "fct" {
+		bal, err = FactoidBalance(ctx, hex.EncodeToString(adr))
+	} else {
+		bal, err = ECBalance(ctx, hex.EncodeToString(adr))
+	}
+	if err != nil {
+		return false, err
+	}
+	if bal != 0 {
+		return true, nil
+	}
+	return Utility.HasTransactions(adr)
+}
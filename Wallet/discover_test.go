@@ -0,0 +1,84 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package Wallet
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/FactomProject/factomd/common/factoid"
+)
+
+func TestDiscoverAddresses(t *testing.T) {
+	ctx := context.Background()
+	mock, cleanup := newTestWallet(t)
+	defer cleanup()
+
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	if err := wallet.RestoreFromMnemonic(mnemonic); err != nil {
+		t.Fatal(err)
+	}
+
+	// Funds at the fourth Factoid address and the second entry credit
+	// address, as if paid to a wallet restored from the mnemonic.
+	pub, _, _, err := wallet.DeriveKey("fct", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fa, err := factoid.NewRCD_1(pub).GetAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.Fund(hex.EncodeToString(fa.Bytes()), 100000000); err != nil {
+		t.Fatal(err)
+	}
+	pub, _, _, err = wallet.DeriveKey("ec", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock.FundEC(hex.EncodeToString(pub), 10)
+	if _, err := mock.ProduceBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first address is in the wallet already, under the name the
+	// fourth would be restored as.
+	if _, err := GenerateAddress("restored-fa-3"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Three unused Factoid addresses stop a search with a gap of two.
+	found, err := DiscoverAddresses(ctx, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 {
+		t.Fatalf("Found %v, expected only the entry credit address", found)
+	}
+	if _, err := LookupAddress("EC", "restored-ec-1"); err != nil {
+		t.Error(err)
+	}
+
+	found, err = DiscoverAddresses(ctx, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 {
+		t.Fatalf("Found %v, expected the funded Factoid address", found)
+	}
+	adr, err := LookupAddress("FA", "restored-fa-3-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if adr != hex.EncodeToString(fa.Bytes()) {
+		t.Error("The funded address was restored under the wrong name")
+	}
+
+	// Nothing new is found again.
+	if found, err := DiscoverAddresses(ctx, 5); err != nil || len(found) != 0 {
+		t.Errorf("Found %v again: %v", found, err)
+	}
+}
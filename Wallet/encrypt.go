@@ -0,0 +1,63 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package Wallet
+
+import (
+	"fmt"
+	"time"
+)
+
+// How long the wallet stays unlocked if no timeout is given
+const DefaultUnlockSeconds = 60
+
+// EncryptWallet sets the passphrase of a wallet that has none, and encrypts
+// all the private keys and seeds already in it.  The wallet is left locked.
+func EncryptWallet(passphrase string) error {
+	if len(passphrase) == 0 {
+		return fmt.Errorf("Missing passphrase")
+	}
+	return wallet.EncryptWallet([]byte(passphrase))
+}
+
+// UnlockWallet makes the private keys of an encrypted wallet usable for the
+// given number of seconds.
+func UnlockWallet(passphrase string, seconds int) (time.Time, error) {
+	if len(passphrase) == 0 {
+		return time.Time{}, fmt.Errorf("Missing passphrase")
+	}
+	if seconds == 0 {
+		seconds = DefaultUnlockSeconds
+	}
+	if seconds < 0 {
+		return time.Time{}, fmt.Errorf("Invalid timeout %d", seconds)
+	}
+	err := wallet.Unlock([]byte(passphrase), time.Duration(seconds)*time.Second)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return wallet.UnlockedUntil(), nil
+}
+
+// ChangePassphrase re-encrypts every private key and seed in the wallet
+// under a new passphrase.  Either everything moves to the new passphrase or
+// nothing does.
+func ChangePassphrase(oldPassphrase string, newPassphrase string) error {
+	if len(oldPassphrase) == 0 || len(newPassphrase) == 0 {
+		return fmt.Errorf("Missing passphrase")
+	}
+	return wallet.ChangePassphrase([]byte(oldPassphrase), []byte(newPassphrase))
+}
+
+func LockWallet() {
+	wallet.Lock()
+}
+
+func IsLocked() (bool, error) {
+	return wallet.IsLocked()
+}
+
+func IsEncrypted() (bool, error) {
+	return wallet.IsEncrypted()
+}
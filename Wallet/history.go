@@ -0,0 +1,56 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package Wallet
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/FactomProject/fctwallet2/Wallet/Utility"
+)
+
+// History returns a page of the synced history of the addresses, given as
+// names, FA or EC addresses, or hex, that passes the filter.  With no
+// addresses, it is the history of every address in the wallet.  The page
+// starts after the cursor, or from the newest transaction if it is empty.
+func History(addresses []string, filter Utility.HistoryFilter, after string, limit int) (*Utility.HistoryPage, error) {
+	filter.Addresses = nil
+	if len(addresses) == 0 {
+		entries, err := GetAddresses()
+		if err != nil {
+			return nil, err
+		}
+		for _, we := range entries {
+			adr, err := we.GetAddress()
+			if err != nil {
+				return nil, err
+			}
+			filter.Addresses = append(filter.Addresses, adr.Bytes())
+		}
+	}
+	for _, a := range addresses {
+		adr, err := historyAddress(a)
+		if err != nil {
+			return nil, err
+		}
+		filter.Addresses = append(filter.Addresses, adr)
+	}
+	return Utility.History(&filter, after, limit)
+}
+
+// historyAddress looks up an address of either type.
+func historyAddress(adr string) ([]byte, error) {
+	adrType := "FA"
+	if Utility.IsValidAddress(adr) && strings.HasPrefix(adr, "EC") {
+		adrType = "EC"
+	} else if we, err := wallet.GetDB().FetchWalletEntryByName([]byte(adr)); err == nil && we != nil && we.GetType() == "ec" {
+		adrType = "EC"
+	}
+	adr, err := LookupAddress(adrType, adr)
+	if err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(adr)
+}
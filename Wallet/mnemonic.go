@@ -0,0 +1,67 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package Wallet
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/FactomProject/factomd/common/primitives"
+
+	"github.com/FactomProject/fctwallet2/scwallet"
+)
+
+// ExportMnemonic returns the mnemonic that backs up the wallet root seed.
+func ExportMnemonic() (string, error) {
+	return wallet.GetMnemonic()
+}
+
+// NewMnemonic gives an empty wallet a fresh root seed with a mnemonic of the
+// given number of words (12 or 24, say), and returns the mnemonic.  With
+// 0 words, it gets the default length.
+func NewMnemonic(words int) (string, error) {
+	if words == 0 {
+		words = scwallet.DefaultMnemonicWords
+	}
+	return wallet.NewMnemonicSeed(words)
+}
+
+// ImportMnemonic initializes an empty wallet from a backup mnemonic, then
+// recreates the first fctCount Factoid and ecCount Entry Credit addresses.
+// If gap is positive, addresses past those are discovered by looking for
+// activity, until gap unused addresses in a row are found.  The addresses
+// are returned in the order they were created.
+func ImportMnemonic(ctx context.Context, mnemonic string, fctCount int, ecCount int, gap int) ([]string, error) {
+	if fctCount < 0 || ecCount < 0 || gap < 0 {
+		return nil, fmt.Errorf("Invalid address count")
+	}
+	if err := wallet.RestoreFromMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+
+	var addresses []string
+	for i := 0; i < fctCount; i++ {
+		addr, err := wallet.GenerateFctAddress([]byte(fmt.Sprintf("restored-fa-%d", i)), 1, 1)
+		if err != nil {
+			return addresses, err
+		}
+		addresses = append(addresses, primitives.ConvertFctAddressToUserStr(addr))
+	}
+	for i := 0; i < ecCount; i++ {
+		addr, err := wallet.GenerateECAddress([]byte(fmt.Sprintf("restored-ec-%d", i)))
+		if err != nil {
+			return addresses, err
+		}
+		addresses = append(addresses, primitives.ConvertECAddressToUserStr(addr))
+	}
+	if gap > 0 {
+		found, err := DiscoverAddresses(ctx, gap)
+		addresses = append(addresses, found...)
+		if err != nil {
+			return addresses, err
+		}
+	}
+	return addresses, nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package Wallet
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/fctwallet2/Wallet/Utility"
+	"github.com/FactomProject/fctwallet2/scwallet"
+)
+
+// ErrMultisigUnsupported is returned for a transaction with a multisig input.
+var ErrMultisigUnsupported = scwallet.ErrMultisigUnsupported
+
+// GenerateMultisigAddress ties an m of n address to the given name.  Each
+// key is either the name of a Factoid address in this wallet, or the public
+// key of another participant in hex.  Every participant must give the same
+// keys in the same order to arrive at the same address.  Funds sent to it
+// can't be spent until factomd checks multisig signatures.
+func GenerateMultisigAddress(name string, m int, keys []string) (interfaces.IAddress, error) {
+	if Utility.IsValidKey(name) == false {
+		return nil, fmt.Errorf("Invalid name or address")
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("No keys given for the multisig address")
+	}
+	publics := make([][]byte, len(keys))
+	for i, key := range keys {
+		pub, err := multisigPublicKey(key)
+		if err != nil {
+			return nil, err
+		}
+		publics[i] = pub
+	}
+	return wallet.AddMultisigAddress([]byte(name), m, publics)
+}
+
+func GenerateMultisigAddressString(name string, m int, keys []string) (string, error) {
+	addr, err := GenerateMultisigAddress(name, m, keys)
+	if err != nil {
+		return "", err
+	}
+	return primitives.ConvertFctAddressToUserStr(addr), nil
+}
+
+func multisigPublicKey(key string) ([]byte, error) {
+	if len(key) == 64 && Utility.IsValidHex(key) {
+		return hex.DecodeString(key)
+	}
+	we, err := GetWalletEntry([]byte(key))
+	if err != nil {
+		return nil, err
+	}
+	if we == nil {
+		return nil, fmt.Errorf("Unknown name '%s'.  Give a name in the wallet or a public key in hex", key)
+	}
+	if we.GetType() != "fct" {
+		return nil, fmt.Errorf("'%s' is not a Factoid address", key)
+	}
+	if _, ok := we.GetRCD().(*factoid.RCD_1); !ok {
+		return nil, fmt.Errorf("'%s' is already a multisig address", key)
+	}
+	return we.GetKey(0), nil
+}
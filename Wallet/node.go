@@ -0,0 +1,93 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package Wallet
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/FactomProject/fctwallet2/Wallet/Node"
+	"github.com/FactomProject/fctwallet2/Wallet/Utility"
+)
+
+func init() {
+	Utility.SetNode(node)
+	Utility.SetStore(wallet.GetDB())
+}
+
+// SetNode points the wallet, and the block scanning in Utility, at a
+// different factomd client, such as a Node.Mock in tests.
+func SetNode(c Node.Client) {
+	node = c
+	Utility.SetNode(c)
+}
+
+func GetNode() Node.Client {
+	return node
+}
+
+// Versions of the factomd API the wallet can talk to.  The v1 REST API is
+// the default; newer nodes also serve the v2 JSON-RPC API.
+const (
+	APIv1 = "v1"
+	APIv2 = "v2"
+)
+
+// How often the factomd nodes are health checked, and how often new blocks
+// are synced from them.
+var (
+	HealthCheckInterval = 30 * time.Second
+	SyncInterval        = 10 * time.Second
+)
+
+// DefaultEndpoint returns the factomd in the configuration, as host:port.
+func DefaultEndpoint() string {
+	return fmt.Sprintf("%s:%d", ipaddressFD, portNumberFD)
+}
+
+// Connect points the wallet at the factomd nodes, given as host:port,
+// over the given version of their API.  The nodes are health checked in
+// the background, and the healthiest one is used.  Blocks are synced from
+// them in the background too.
+func Connect(version string, endpoints []string) error {
+	if len(endpoints) == 0 {
+		return fmt.Errorf("No factomd nodes given")
+	}
+	pool := Node.NewPool()
+	for _, endpoint := range endpoints {
+		host, port, err := net.SplitHostPort(endpoint)
+		if err != nil {
+			return err
+		}
+		p, err := strconv.Atoi(port)
+		if err != nil {
+			return fmt.Errorf("Invalid port in %s", endpoint)
+		}
+		switch version {
+		case APIv1:
+			pool.Add(endpoint, Node.NewHTTPClient(host, p))
+		case APIv2:
+			pool.Add(endpoint, Node.NewRPCClient(host, p))
+		default:
+			return fmt.Errorf("Unknown factomd API %s. Use %s or %s", version, APIv1, APIv2)
+		}
+	}
+	SetNode(pool)
+	go pool.Run(context.Background(), HealthCheckInterval)
+	go Utility.RunSync(context.Background(), SyncInterval)
+	return nil
+}
+
+// CurrentNode returns the factomd node in use, or an empty string if the
+// wallet isn't using a pool of nodes.
+func CurrentNode() string {
+	if pool, ok := node.(*Node.Pool); ok {
+		return pool.Current()
+	}
+	return ""
+}
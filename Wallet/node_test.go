@@ -0,0 +1,147 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package Wallet
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/FactomProject/fctwallet2/Wallet/Node"
+	"github.com/FactomProject/fctwallet2/Wallet/Utility"
+	"github.com/FactomProject/fctwallet2/scwallet"
+)
+
+// newTestWallet swaps in an empty wallet talking to a mock factomd, until
+// the cleanup is called.
+func newTestWallet(t *testing.T) (*Node.Mock, func()) {
+	dir, err := ioutil.TempDir("", "fctwallet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldWallet, oldNode := wallet, node
+	wallet = scwallet.NewSCWallet(dir+"/", "test_wallet.db")
+	wallet.NewSeed([]byte("lkdfsgjlagkjlasd"))
+	Utility.SetStore(wallet.GetDB())
+	mock := Node.NewMock(1000)
+	SetNode(mock)
+	return mock, func() {
+		wallet = oldWallet
+		Utility.SetStore(wallet.GetDB())
+		SetNode(oldNode)
+		os.RemoveAll(dir)
+	}
+}
+
+func TestSendAndCommit(t *testing.T) {
+	ctx := context.Background()
+	mock, cleanup := newTestWallet(t)
+	defer cleanup()
+
+	for _, name := range []string{"alice", "bob"} {
+		if _, err := GenerateAddress(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := GenerateECAddress("ec"); err != nil {
+		t.Fatal(err)
+	}
+	alice, err := LookupAddress("FA", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mock.Fund(alice, 100000000); err != nil {
+		t.Fatal(err)
+	}
+
+	txid, fee, err := Send(ctx, []Payment{{Address: "bob", Amount: 10000000}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mock.Pending()) != 1 || mock.Pending()[0].GetSigHash().String() != txid {
+		t.Fatal("The transaction did not reach the node")
+	}
+	if bal, _ := FactoidBalance(ctx, "bob"); bal != 10000000 {
+		t.Errorf("bob has %d, expected 10000000", bal)
+	}
+	if bal, _ := FactoidBalance(ctx, "alice"); bal != 0 {
+		t.Errorf("alice has %d, expected 0", bal)
+	}
+	change := int64(90000000 - fee)
+	if bal, _ := FactoidBalance(ctx, scwallet.ChangeAddressPrefix+"0"); bal != change {
+		t.Errorf("The change address has %d, expected %d", bal, change)
+	}
+
+	purchase, err := BuyEntryCredits(ctx, scwallet.ChangeAddressPrefix+"0", "ec", 20, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !purchase.Confirmed || purchase.Balance != 20 {
+		t.Errorf("Bought 20 entry credits, but have %d", purchase.Balance)
+	}
+
+	// An unsigned entry commit paying one entry credit.
+	msg := make([]byte, 40)
+	msg[39] = 1
+	data, err := json.Marshal(struct{ Message string }{hex.EncodeToString(msg)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CommitEntry(ctx, "ec", data); err != nil {
+		t.Fatal(err)
+	}
+	if mock.Commits() != 1 {
+		t.Error("The commit did not reach the node")
+	}
+	if bal, _ := ECBalance(ctx, "ec"); bal != 19 {
+		t.Errorf("Entry credit balance is %d after the commit, expected 19", bal)
+	}
+
+	if _, err := mock.ProduceBlock(); err != nil {
+		t.Fatal(err)
+	}
+	bob, _ := LookupAddress("FA", "bob")
+	adr, _ := hex.DecodeString(bob)
+	if err := Utility.Sync(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if found, err := Utility.HasTransactions(adr); err != nil || !found {
+		t.Errorf("The payment to bob is not in the blocks: %v", err)
+	}
+
+	adr, _ = hex.DecodeString(alice)
+	txs, err := Utility.GetAddressTxs(adr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txs) != 2 || txs[0].Direction != Utility.Received || txs[0].Index != 0 ||
+		txs[1].Direction != Utility.Sent || txs[1].Amount != 100000000 || txs[1].TxID == nil {
+		t.Errorf("alice should be paid by the coinbase, then pay bob: %+v", txs)
+	}
+	ec, _ := LookupAddress("EC", "ec")
+	adr, _ = hex.DecodeString(ec)
+	txs, err = Utility.GetAddressTxs(adr)
+	if err != nil || len(txs) != 2 || txs[0].Direction != Utility.Received || txs[0].Amount != 20 ||
+		txs[1].Direction != Utility.Committed || txs[1].Amount != 1 {
+		t.Errorf("Expected a purchase of 20 entry credits, then a commit of 1: %+v %v", txs, err)
+	}
+
+	// Balances computed from the blocks match the node's once everything is
+	// in a block.
+	if bal, err := LocalECBalance("ec"); err != nil || bal != 19 {
+		t.Errorf("Entry credit balance from the blocks is %d, expected 19: %v", bal, err)
+	}
+	check, err := CheckBalance(ctx, "FA", "bob")
+	if err != nil || !check.Matches() || check.Local != 10000000 {
+		t.Errorf("bob's balance from the blocks doesn't match the node's: %+v %v", check, err)
+	}
+	mock.Fund(bob, 5)
+	if check, err := CheckBalance(ctx, "FA", "bob"); err != nil || check.Matches() {
+		t.Errorf("A payment waiting for a block doesn't show up as a difference: %+v %v", check, err)
+	}
+}
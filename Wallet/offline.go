@@ -0,0 +1,36 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package Wallet
+
+import (
+	"fmt"
+)
+
+// An offline wallet holds keys and signs, but never talks to factomd.  It
+// is paired with an online wallet that builds transactions and submits
+// them.  Transactions go between the two with ExportTransaction and
+// ImportTransaction, and come back signed to be merged or submitted with
+// SubmitTransaction.  Fees can't be looked up offline, so the fee rate has
+// to be given explicitly.
+
+var ErrOffline = fmt.Errorf("The wallet is offline and does not talk to factomd")
+
+var offline bool
+
+// SetOffline puts the wallet in or out of offline mode.
+func SetOffline(o bool) {
+	offline = o
+}
+
+func IsOffline() bool {
+	return offline
+}
+
+func checkOnline() error {
+	if offline {
+		return ErrOffline
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package Wallet
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/fctwallet2/Wallet/Utility"
+)
+
+// A partially signed transaction is a transaction under construction in hex,
+// in the same binary form it is kept in the wallet: inputs, outputs, the RCD
+// of each input and whatever signatures have been collected so far.  Each
+// party to a multisig input imports it, signs what they can, and sends it
+// back to be merged.  factomd doesn't check multisig signatures yet, so such
+// a transaction can't be submitted until it does.
+
+// ExportTransaction returns the transaction under the key as a partially
+// signed transaction.
+func ExportTransaction(key string) (string, error) {
+	if Utility.IsValidKey(key) == false {
+		return "", fmt.Errorf("Invalid name for transaction")
+	}
+	trans, err := GetTransaction(key)
+	if err != nil {
+		return "", err
+	}
+	if trans == nil {
+		return "", fmt.Errorf("Unknown transaction '%s'", key)
+	}
+	data, err := trans.MarshalBinary()
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(data), nil
+}
+
+// ImportTransaction saves a partially signed transaction under the key, so
+// it can be signed, merged or submitted like any other.
+func ImportTransaction(key string, pst string) error {
+	if Utility.IsValidKey(key) == false {
+		return fmt.Errorf("Invalid name for transaction")
+	}
+	t, err := wallet.GetDB().FetchTransaction([]byte(key))
+	if err != nil {
+		return err
+	}
+	if t != nil {
+		return fmt.Errorf("Duplicate key: '%s'", key)
+	}
+	trans, err := decodeTransaction(pst)
+	if err != nil {
+		return err
+	}
+	return wallet.GetDB().SaveTransaction([]byte(key), trans)
+}
+
+// MergeTransaction adds the signatures from a partially signed copy of the
+// transaction under the key.  Returns true once every input is signed.
+func MergeTransaction(key string, pst string) (bool, error) {
+	trans, err := GetTransaction(key)
+	if err != nil {
+		return false, err
+	}
+	if trans == nil {
+		return false, fmt.Errorf("Unknown transaction '%s'", key)
+	}
+	other, err := decodeTransaction(pst)
+	if err != nil {
+		return false, err
+	}
+	if err := wallet.MergeSignatures(trans, other); err != nil {
+		return false, err
+	}
+	err = wallet.GetDB().SaveTransaction([]byte(key), trans)
+	if err != nil {
+		return false, err
+	}
+	return wallet.ValidateSignatures(trans) == nil, nil
+}
+
+// CosignTransaction signs every input of the transaction under the key that
+// this wallet holds keys for, and keeps the signatures even if others are
+// still needed.  Returns true once every input is signed.
+func CosignTransaction(key string) (bool, error) {
+	if Utility.IsValidKey(key) == false {
+		return false, fmt.Errorf("Invalid name for transaction")
+	}
+	trans, err := GetTransaction(key)
+	if err != nil {
+		return false, err
+	}
+	if trans == nil {
+		return false, fmt.Errorf("Unknown transaction '%s'", key)
+	}
+	if _, err := wallet.SignInputs(trans); err != nil {
+		return false, err
+	}
+	err = wallet.GetDB().SaveTransaction([]byte(key), trans)
+	if err != nil {
+		return false, err
+	}
+	return wallet.ValidateSignatures(trans) == nil, nil
+}
+
+// copyTransaction returns a copy of the transaction that can be changed
+// without touching the original.
+func copyTransaction(trans interfaces.ITransaction) (interfaces.ITransaction, error) {
+	data, err := trans.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return decodeTransaction(hex.EncodeToString(data))
+}
+
+// hasMultisigInput is true if any input of the transaction is from a
+// multisig address.
+func hasMultisigInput(trans interfaces.ITransaction) bool {
+	for _, rcd := range trans.GetRCDs() {
+		if _, ok := rcd.(*factoid.RCD_2); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func decodeTransaction(pst string) (interfaces.ITransaction, error) {
+	data, err := hex.DecodeString(pst)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid transaction format")
+	}
+	trans := new(factoid.Transaction)
+	if err := trans.UnmarshalBinary(data); err != nil {
+		return nil, fmt.Errorf("Invalid transaction: %v", err)
+	}
+	if len(trans.GetRCDs()) != len(trans.GetInputs()) {
+		return nil, fmt.Errorf("Invalid transaction: every input needs an RCD")
+	}
+	return trans, nil
+}
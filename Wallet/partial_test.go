@@ -0,0 +1,55 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package Wallet
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSubmitMultisigRefused(t *testing.T) {
+	ctx := context.Background()
+	mock, cleanup := newTestWallet(t)
+	defer cleanup()
+
+	for _, name := range []string{"a", "b"} {
+		if _, err := GenerateAddress(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	shared, err := GenerateMultisigAddress("shared", 2, []string{"a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := FactoidNewTransaction("spend"); err != nil {
+		t.Fatal(err)
+	}
+	trans, err := GetTransaction("spend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := FactoidAddInput(trans, "spend", shared, 1000000); err != nil {
+		t.Fatal(err)
+	}
+	if err := FactoidAddOutput(trans, "spend", shared, 1000000-12000); err != nil {
+		t.Fatal(err)
+	}
+	// The wallet holds both keys, so it signs every slot, but factomd
+	// can't check them.
+	if _, err := CosignTransaction("spend"); err != nil {
+		t.Fatal(err)
+	}
+	pst, err := ExportTransaction("spend")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SubmitTransaction(ctx, pst); err != ErrMultisigUnsupported {
+		t.Errorf("Expected %v, got %v", ErrMultisigUnsupported, err)
+	}
+	if len(mock.Pending()) != 0 {
+		t.Error("The multisig transaction reached the node")
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package Wallet
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/fctwallet2/scwallet"
+)
+
+// A Payment is an amount of factoshis to send to an address, given as an FA
+// address or the name of an address in the wallet.
+type Payment struct {
+	Address string
+	Amount  uint64
+}
+
+// A coin is an address of ours that can pay for a transaction, and its
+// balance.
+type coin struct {
+	address interfaces.IAddress
+	balance uint64
+}
+
+type output struct {
+	address interfaces.IAddress
+	amount  uint64
+}
+
+// Send pays each of the payments in one transaction.  Inputs are chosen
+// from the Factoid addresses in the wallet, largest balance first, until
+// they cover the payments and the fee.  What is left over goes to the
+// change address.  The transaction is signed and submitted, and
+// its id returned along with the fee paid.
+func Send(ctx context.Context, payments []Payment) (string, uint64, error) {
+	if err := checkOnline(); err != nil {
+		return "", 0, err
+	}
+	outputs, err := resolvePayments(payments)
+	if err != nil {
+		return "", 0, err
+	}
+	rate, err := GetFee(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+	coins, err := spendableCoins(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+
+	trans, fee, err := composeSend(coins, outputs, uint64(rate), wallet.ChangeAddress)
+	if err != nil {
+		return "", 0, err
+	}
+
+	signed, err := wallet.SignInputs(trans)
+	if err != nil {
+		return "", 0, err
+	}
+	if !signed {
+		return "", 0, fmt.Errorf("Do not have all the private keys required to sign this transaction")
+	}
+	if err := submitTransaction(ctx, trans); err != nil {
+		return "", 0, err
+	}
+	return trans.GetSigHash().String(), fee, nil
+}
+
+// composeSend builds a transaction paying the outputs, with as few of the
+// coins as will cover them and the fee at the given rate.  The change
+// address is only asked for if there is change.
+func composeSend(coins []coin, outputs []output, rate uint64, changeAddress func() (interfaces.IAddress, error)) (interfaces.ITransaction, uint64, error) {
+	var total uint64
+	for _, o := range outputs {
+		if o.amount == 0 {
+			return nil, 0, fmt.Errorf("Cannot send an amount of zero")
+		}
+		total += o.amount
+	}
+
+	sort.Sort(byBalance(coins))
+	var available uint64
+	for n := 1; n <= len(coins); n++ {
+		inputs := coins[:n]
+		available += inputs[n-1].balance
+
+		// Work out the fee with a change output, which is the larger case.
+		// Any address will do to size it.
+		change := output{address: inputs[0].address}
+		trans, err := buildTransaction(inputs, append(outputs, change))
+		if err != nil {
+			return nil, 0, err
+		}
+		fee, err := trans.CalculateFee(rate)
+		if err != nil {
+			return nil, 0, err
+		}
+		if available < total+fee {
+			continue
+		}
+
+		change.amount = available - total - fee
+		if change.amount == 0 {
+			trans, err = buildTransaction(inputs, outputs)
+		} else {
+			change.address, err = changeAddress()
+			if err != nil {
+				return nil, 0, err
+			}
+			trans, err = buildTransaction(inputs, append(outputs, change))
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		return trans, fee, nil
+	}
+	return nil, 0, fmt.Errorf("Insufficient funds: need %s plus the fee, have %s",
+		primitives.ConvertDecimalToString(total), primitives.ConvertDecimalToString(available))
+}
+
+func buildTransaction(inputs []coin, outputs []output) (interfaces.ITransaction, error) {
+	trans := wallet.CreateTransaction(interfaces.GetTimeMilli())
+	for _, c := range inputs {
+		if err := wallet.AddInput(trans, c.address, c.balance); err != nil {
+			return nil, err
+		}
+	}
+	// Outputs to the same address are combined
+	var merged []output
+	for _, o := range outputs {
+		found := false
+		for i := range merged {
+			if bytes.Equal(merged[i].address.Bytes(), o.address.Bytes()) {
+				merged[i].amount += o.amount
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged = append(merged, o)
+		}
+	}
+	for _, o := range merged {
+		if err := wallet.AddOutput(trans, o.address, o.amount); err != nil {
+			return nil, err
+		}
+	}
+	return trans, nil
+}
+
+// spendableCoins returns the Factoid addresses in the wallet this wallet
+// can sign for on its own, that have a balance.
+func spendableCoins(ctx context.Context) ([]coin, error) {
+	entries, err := GetAddresses()
+	if err != nil {
+		return nil, err
+	}
+	var coins []coin
+	for _, we := range entries {
+		e, ok := we.(*scwallet.WalletEntry)
+		if !ok || we.GetType() != "fct" || !e.IsSingleKey() || e.IsWatchOnly() {
+			continue
+		}
+		adr, err := we.GetAddress()
+		if err != nil {
+			return nil, err
+		}
+		bal, err := FactoidBalance(ctx, hex.EncodeToString(adr.Bytes()))
+		if err != nil {
+			return nil, err
+		}
+		if bal > 0 {
+			coins = append(coins, coin{address: adr, balance: uint64(bal)})
+		}
+	}
+	return coins, nil
+}
+
+func resolvePayments(payments []Payment) ([]output, error) {
+	if len(payments) == 0 {
+		return nil, fmt.Errorf("No payments given")
+	}
+	outputs := make([]output, len(payments))
+	for i, p := range payments {
+		adr, err := LookupAddress("FA", p.Address)
+		if err != nil {
+			return nil, err
+		}
+		data, err := hex.DecodeString(adr)
+		if err != nil {
+			return nil, err
+		}
+		outputs[i] = output{address: factoid.NewAddress(data), amount: p.Amount}
+	}
+	return outputs, nil
+}
+
+type byBalance []coin
+
+func (c byBalance) Len() int           { return len(c) }
+func (c byBalance) Swap(i, j int)      { c[i], c[j] = c[j], c[i] }
+func (c byBalance) Less(i, j int) bool { return c[i].balance > c[j].balance }
@@ -6,6 +6,7 @@ package Wallet
 
 import (
 	"github.com/FactomProject/factomd/util"
+	"github.com/FactomProject/fctwallet2/Wallet/Node"
 	"github.com/FactomProject/fctwallet2/scwallet"
 )
 
@@ -21,4 +22,6 @@ var (
 
 var wallet = scwallet.NewSCWallet(cfg.BoltDBPath, databasefile)
 
+var node Node.Client = Node.NewHTTPClient(ipaddressFD, portNumberFD)
+
 const Version = "0.1.5"
@@ -0,0 +1,115 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package Wallet
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// Sweep moves the whole balance of an address, less the fee, to the target
+// address.  The source is either the name or FA address of an address in
+// the wallet, or an Fs private key, which is used to sign but not stored.
+// Returns the transaction id, the amount moved, and the fee.
+func Sweep(ctx context.Context, source string, target string) (string, uint64, uint64, error) {
+	if err := checkOnline(); err != nil {
+		return "", 0, 0, err
+	}
+	to, err := resolvePayments([]Payment{{Address: target}})
+	if err != nil {
+		return "", 0, 0, err
+	}
+
+	// What AddInput needs to find the RCD: the address for one of ours,
+	// the public key for a key we don't store.
+	var from interfaces.IAddress
+	var address interfaces.IAddress
+	var privateKey []byte
+	if primitives.ValidateFPrivateUserStr(source) {
+		privateKey, err = primitives.HumanReadableFactoidPrivateKeyToPrivateKey(source)
+		if err != nil {
+			return "", 0, 0, err
+		}
+		pub, _, err := primitives.GenerateKeyFromPrivateKey(privateKey)
+		if err != nil {
+			return "", 0, 0, err
+		}
+		from = factoid.NewAddress(pub)
+		address, err = factoid.NewRCD_1(pub).GetAddress()
+		if err != nil {
+			return "", 0, 0, err
+		}
+	} else {
+		adr, err := LookupAddress("FA", source)
+		if err != nil {
+			return "", 0, 0, err
+		}
+		data, err := hex.DecodeString(adr)
+		if err != nil {
+			return "", 0, 0, err
+		}
+		from = factoid.NewAddress(data)
+		if _, err := wallet.GetAddressHash(from); err != nil {
+			return "", 0, 0, fmt.Errorf("%s is not an address in the wallet. Give its private key to sweep it", source)
+		}
+		address = from
+	}
+	if address.IsSameAs(to[0].address) {
+		return "", 0, 0, fmt.Errorf("Cannot sweep an address into itself")
+	}
+
+	balance, err := FactoidBalance(ctx, hex.EncodeToString(address.Bytes()))
+	if err != nil {
+		return "", 0, 0, err
+	}
+	if balance <= 0 {
+		return "", 0, 0, fmt.Errorf("Nothing to sweep from %s", primitives.ConvertFctAddressToUserStr(address))
+	}
+
+	rate, err := GetFee(ctx)
+	if err != nil {
+		return "", 0, 0, err
+	}
+	inputs := []coin{{address: from, balance: uint64(balance)}}
+	trans, err := buildTransaction(inputs, to)
+	if err != nil {
+		return "", 0, 0, err
+	}
+	fee, err := trans.CalculateFee(uint64(rate))
+	if err != nil {
+		return "", 0, 0, err
+	}
+	if uint64(balance) <= fee {
+		return "", 0, 0, fmt.Errorf("The balance of %s does not cover the fee of %s",
+			primitives.ConvertDecimalToString(uint64(balance)), primitives.ConvertDecimalToString(fee))
+	}
+	to[0].amount = uint64(balance) - fee
+	trans, err = buildTransaction(inputs, to)
+	if err != nil {
+		return "", 0, 0, err
+	}
+
+	var signed bool
+	if privateKey != nil {
+		signed, err = wallet.SignInputsWithKey(trans, privateKey)
+	} else {
+		signed, err = wallet.SignInputs(trans)
+	}
+	if err != nil {
+		return "", 0, 0, err
+	}
+	if !signed {
+		return "", 0, 0, fmt.Errorf("Do not have all the private keys required to sign this transaction")
+	}
+	if err := submitTransaction(ctx, trans); err != nil {
+		return "", 0, 0, err
+	}
+	return trans.GetSigHash().String(), to[0].amount, fee, nil
+}
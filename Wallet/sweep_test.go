@@ -0,0 +1,91 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package Wallet
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+func TestSweep(t *testing.T) {
+	ctx := context.Background()
+	mock, cleanup := newTestWallet(t)
+	defer cleanup()
+
+	if _, err := GenerateAddress("target"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A paper wallet: a private key the wallet doesn't hold.
+	priv := bytes.Repeat([]byte{7}, 32)
+	pub, _, err := primitives.GenerateKeyFromPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := primitives.ConvertFctPrivateToUserStr(factoid.NewAddress(priv))
+	source, err := factoid.NewRCD_1(pub).GetAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fa := hex.EncodeToString(source.Bytes())
+	if err := mock.Fund(fa, 100000000); err != nil {
+		t.Fatal(err)
+	}
+
+	txid, amount, fee, err := Sweep(ctx, fs, "target")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amount+fee != 100000000 {
+		t.Errorf("Swept %d with a fee of %d out of 100000000", amount, fee)
+	}
+	if len(mock.Pending()) != 1 || mock.Pending()[0].GetSigHash().String() != txid {
+		t.Fatal("The sweep did not reach the node")
+	}
+	if bal, _ := FactoidBalance(ctx, "target"); bal != int64(amount) {
+		t.Errorf("target has %d, expected %d", bal, amount)
+	}
+	if bal, _ := FactoidBalance(ctx, fa); bal != 0 {
+		t.Errorf("%d left behind after the sweep", bal)
+	}
+	if we, err := wallet.GetDB().FetchWalletEntryByPublicKey(pub); err != nil || we != nil {
+		t.Error("The swept private key was stored in the wallet")
+	}
+
+	// A balance that exactly covers the fee leaves nothing to sweep, one
+	// factoshi more sweeps that factoshi.
+	if err := mock.Fund(fa, fee); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := Sweep(ctx, fs, "target"); err == nil {
+		t.Error("Swept a balance that only pays the fee")
+	}
+	if err := mock.Fund(fa, 1); err != nil {
+		t.Fatal(err)
+	}
+	_, amount, fee2, err := Sweep(ctx, fs, "target")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amount != 1 || fee2 != fee {
+		t.Errorf("Swept %d with a fee of %d, expected 1 with %d", amount, fee2, fee)
+	}
+
+	// An address can't be swept into itself, named or by its private key.
+	if _, _, _, err := Sweep(ctx, "target", "target"); err == nil {
+		t.Error("Swept target into itself")
+	}
+	if err := mock.Fund(fa, 100000000); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := Sweep(ctx, fs, primitives.ConvertFctAddressToUserStr(source)); err == nil {
+		t.Error("Swept a private key into its own address")
+	}
+}
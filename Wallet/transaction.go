@@ -6,11 +6,9 @@ package Wallet
 
 import (
 	"bytes"
-	"encoding/hex"
+	"context"
 	"encoding/json"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 
 	"github.com/FactomProject/factomd/common/factoid"
 	"github.com/FactomProject/factomd/common/interfaces"
@@ -64,7 +62,20 @@ func FactoidDeleteTransaction(key string) error {
 	return wallet.GetDB().DeleteTransaction([]byte(key))
 }
 
-func FactoidAddFee(trans interfaces.ITransaction, key string, address interfaces.IAddress, name string) (uint64, error) {
+func FactoidAddFee(ctx context.Context, trans interfaces.ITransaction, key string, address interfaces.IAddress, name string) (uint64, error) {
+	fee, err := GetFee(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return FactoidAddFeeAtRate(trans, key, address, name, uint64(fee))
+}
+
+// FactoidAddFeeAtRate is FactoidAddFee with the fee rate given rather than
+// asked of factomd, as it must be when the wallet is offline.
+//
+// If the inputs are more than the outputs, the fee comes out of the
+// difference, and whatever is left goes to the change address.
+func FactoidAddFeeAtRate(trans interfaces.ITransaction, key string, address interfaces.IAddress, name string, rate uint64) (uint64, error) {
 	ins, err := trans.TotalInputs()
 	if err != nil {
 		return 0, err
@@ -78,7 +89,7 @@ func FactoidAddFee(trans interfaces.ITransaction, key string, address interfaces
 		return 0, err
 	}
 
-	if ins != outs+ecs {
+	if ins < outs+ecs {
 		return 0, fmt.Errorf("Inputs and outputs don't add up")
 	}
 
@@ -87,19 +98,44 @@ func FactoidAddFee(trans interfaces.ITransaction, key string, address interfaces
 		return 0, fmt.Errorf("Invalid name for transaction")
 	}
 
-	fee, err := GetFee()
+	transfee, err := trans.CalculateFee(rate)
 	if err != nil {
 		return 0, err
 	}
 
-	transfee, err := trans.CalculateFee(uint64(fee))
+	adr, err := wallet.GetAddressHash(address)
 	if err != nil {
 		return 0, err
 	}
 
-	adr, err := wallet.GetAddressHash(address)
-	if err != nil {
-		return 0, err
+	if surplus := ins - outs - ecs; surplus > 0 {
+		// Size the fee with a change output before deciding to add one
+		scratch, err := copyTransaction(trans)
+		if err != nil {
+			return 0, err
+		}
+		if err := wallet.AddOutput(scratch, adr, 0); err != nil {
+			return 0, err
+		}
+		withChange, err := scratch.CalculateFee(rate)
+		if err != nil {
+			return 0, err
+		}
+		if surplus > withChange {
+			change, err := wallet.ChangeAddress()
+			if err != nil {
+				return 0, err
+			}
+			if err := wallet.AddOutput(trans, change, surplus-withChange); err != nil {
+				return 0, err
+			}
+			return withChange, wallet.GetDB().SaveTransaction([]byte(key), trans)
+		}
+		if surplus >= transfee {
+			// Too little left over to be worth a change output
+			return surplus, nil
+		}
+		transfee -= surplus
 	}
 
 	for _, input := range trans.GetInputs() {
@@ -213,13 +249,12 @@ func FactoidSignTransaction(key string) error {
 	}
 
 	valid, err := wallet.SignInputs(trans)
-	if !valid {
-		return fmt.Errorf("Do not have all the private keys required to sign this transaction\n" +
-			err.Error())
-	}
 	if err != nil {
 		return err
 	}
+	if !valid {
+		return fmt.Errorf("Do not have all the private keys required to sign this transaction")
+	}
 
 	err = wallet.ValidateSignatures(trans)
 	if err != nil {
@@ -231,7 +266,7 @@ func FactoidSignTransaction(key string) error {
 	return wallet.GetDB().SaveTransaction([]byte(key), trans)
 }
 
-func FactoidSubmit(jsonkey string) (string, error) {
+func FactoidSubmit(ctx context.Context, jsonkey string) (string, error) {
 	type submitReq struct {
 		Transaction string
 	}
@@ -248,71 +283,58 @@ func FactoidSubmit(jsonkey string) (string, error) {
 
 	fmt.Printf("Fetched transaction - %v\n", trans)
 
-	err = wallet.ValidateSignatures(trans)
+	err = submitTransaction(ctx, trans)
 	if err != nil {
-		fmt.Printf("Signature invalid - %v\n", err)
-		fmt.Println(err)
 		return "", err
 	}
+	wallet.GetDB().DeleteTransaction([]byte(key))
+	return "", nil
+}
 
-	err = isReasonableFee(trans)
+// SubmitTransaction sends a signed transaction exported from another
+// wallet, such as an offline wallet that holds the keys, to factomd.
+func SubmitTransaction(ctx context.Context, pst string) error {
+	trans, err := decodeTransaction(pst)
 	if err != nil {
-		fmt.Println(err)
-		return "", err
+		return err
 	}
+	return submitTransaction(ctx, trans)
+}
 
-	// Okay, transaction is good, so marshal and send to factomd!
-	data, err := trans.MarshalBinary()
-	if err != nil {
-		fmt.Printf("Error marshalling transaction - %v\n", err)
-		fmt.Println(err)
-		return "", err
+func submitTransaction(ctx context.Context, trans interfaces.ITransaction) error {
+	if err := checkOnline(); err != nil {
+		return err
 	}
 
-	transdata := string(hex.EncodeToString(data))
-
-	s := struct{ Transaction string }{transdata}
-
-	j, err := json.Marshal(s)
-	if err != nil {
-		fmt.Println(err)
-		return "", err
+	if hasMultisigInput(trans) {
+		return ErrMultisigUnsupported
 	}
-
-	fmt.Printf("Encoded transaction - %v\n", j)
-
-	resp, err := http.Post(
-		fmt.Sprintf("http://%s:%d/v1/factoid-submit/", ipaddressFD, portNumberFD),
-		"application/json",
-		bytes.NewBuffer(j))
-
+	// Partially signed transactions stay in the wallet until the last
+	// signature is merged in.
+	err := wallet.ValidateSignatures(trans)
 	if err != nil {
-		return "", err
+		fmt.Printf("Signature invalid - %v\n", err)
+		return fmt.Errorf("Transaction is not fully signed: %v", err)
 	}
 
-	body, err := ioutil.ReadAll(resp.Body)
+	err = isReasonableFee(ctx, trans)
 	if err != nil {
-		return "", err
+		fmt.Println(err)
+		return err
 	}
 
-	resp.Body.Close()
-
-	r := new(Response)
-	if err := json.Unmarshal(body, r); err != nil {
-		return "", err
+	// Okay, transaction is good, so marshal and send to factomd!
+	data, err := trans.MarshalBinary()
+	if err != nil {
+		fmt.Printf("Error marshalling transaction - %v\n", err)
+		return err
 	}
 
-	if r.Success {
-		wallet.GetDB().DeleteTransaction([]byte(key))
-		return "", nil
-	} else {
-		return "", fmt.Errorf(r.Response)
-	}
-	return r.Response, nil
+	return node.FactoidSubmit(ctx, data)
 }
 
-func isReasonableFee(trans interfaces.ITransaction) error {
-	feeRate, getErr := GetFee()
+func isReasonableFee(ctx context.Context, trans interfaces.ITransaction) error {
+	feeRate, getErr := GetFee(ctx)
 	if getErr != nil {
 		return getErr
 	}
@@ -352,58 +374,23 @@ func isReasonableFee(trans interfaces.ITransaction) error {
 	return nil
 }
 
-func GetFee() (int64, error) {
-	str := fmt.Sprintf("http://%s:%d/v1/factoid-get-fee/", ipaddressFD, portNumberFD)
-	resp, err := http.Get(str)
-	if err != nil {
-		return 0, err
-	}
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		resp.Body.Close()
+func GetFee(ctx context.Context) (int64, error) {
+	if err := checkOnline(); err != nil {
 		return 0, err
 	}
-	resp.Body.Close()
-
-	type x struct {
-		Response struct {
-			Fee int64
-		}
-		Success bool
-	}
-	b := new(x)
-	if err := json.Unmarshal(body, b); err != nil {
-		return 0, err
-	}
-
-	return b.Response.Fee, nil
+	return node.GetFee(ctx)
 }
 
-func GetProperties() (protocol, factomd, fctwallet string, err error) {
-	type prop struct {
-		Protocol_Version  string
-		Factomd_Version   string
-		Fctwallet_Version string
-	}
-
-	str := fmt.Sprintf("http://%s:%d/v1/properties/", ipaddressFD, portNumberFD)
-	resp, err := http.Get(str)
-	if err != nil {
+func GetProperties(ctx context.Context) (protocol, factomd, fctwallet string, err error) {
+	if err := checkOnline(); err != nil {
 		return "", "", "", err
 	}
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		resp.Body.Close()
-		return "", "", "", err
-	}
-	resp.Body.Close()
 
-	b := new(prop)
-	if err := json.Unmarshal(body, b); err != nil {
+	protocol, factomd, err = node.GetProperties(ctx)
+	if err != nil {
 		return "", "", "", err
 	}
-	b.Fctwallet_Version = Version
-	return b.Protocol_Version, b.Factomd_Version, Version, nil
+	return protocol, factomd, Version, nil
 }
 
 func GetAddresses() ([]interfaces.IWalletEntry, error) {
@@ -0,0 +1,68 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package Wallet
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/fctwallet2/Wallet/Utility"
+	"github.com/FactomProject/fctwallet2/scwallet"
+)
+
+// ImportWatchOnly ties an address the wallet has no keys for to the name,
+// so its balance and transactions can be followed.  The address is either
+// an FA or EC address, or a public key in hex.  A public key is taken to be
+// for a Factoid address unless addrtype is "ec".  Only addresses imported
+// with their public key can be used as transaction inputs, to be signed
+// elsewhere.
+func ImportWatchOnly(name string, address string, addrtype string) (interfaces.IAddress, error) {
+	if Utility.IsValidKey(name) == false {
+		return nil, fmt.Errorf("Invalid name or address")
+	}
+	switch {
+	case primitives.ValidateFUserStr(address):
+		return wallet.AddWatchOnlyAddress([]byte(name), primitives.ConvertUserStrToAddress(address))
+	case primitives.ValidateECUserStr(address):
+		// Entry Credit addresses are the public key
+		return wallet.AddWatchOnlyKey("ec", []byte(name), primitives.ConvertUserStrToAddress(address))
+	case len(address) == 64 && Utility.IsValidHex(address):
+		pub, err := hex.DecodeString(address)
+		if err != nil {
+			return nil, err
+		}
+		if addrtype == "" {
+			addrtype = "fct"
+		}
+		if addrtype != "fct" && addrtype != "ec" {
+			return nil, fmt.Errorf("Invalid address type '%s'", addrtype)
+		}
+		return wallet.AddWatchOnlyKey(addrtype, []byte(name), pub)
+	}
+	return nil, fmt.Errorf("Give an FA or EC address, or a public key in hex")
+}
+
+func ImportWatchOnlyString(name string, address string, addrtype string) (string, error) {
+	adr, err := ImportWatchOnly(name, address, addrtype)
+	if err != nil {
+		return "", err
+	}
+	we, err := GetWalletEntry([]byte(name))
+	if err != nil {
+		return "", err
+	}
+	if we.GetType() == "ec" {
+		return primitives.ConvertECAddressToUserStr(adr), nil
+	}
+	return primitives.ConvertFctAddressToUserStr(adr), nil
+}
+
+// IsWatchOnly is true for an address the wallet can't sign for.
+func IsWatchOnly(we interfaces.IWalletEntry) bool {
+	e, ok := we.(*scwallet.WalletEntry)
+	return ok && e.IsWatchOnly()
+}
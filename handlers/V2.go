@@ -5,6 +5,8 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
 	"github.com/FactomProject/factomd/common/primitives"
 	"github.com/FactomProject/factomd/wsapi"
 	"github.com/FactomProject/web"
@@ -35,9 +37,9 @@ func HandleV2(ctx *web.Context, post bool) {
 	var jsonResp *primitives.JSON2Response
 	var jsonError *primitives.JSONError
 	if post == true {
-		jsonResp, jsonError = HandleV2PostRequest(j)
+		jsonResp, jsonError = HandleV2PostRequest(ctx.Request.Context(), j)
 	} else {
-		jsonResp, jsonError = HandleV2GetRequest(j)
+		jsonResp, jsonError = HandleV2GetRequest(ctx.Request.Context(), j)
 	}
 
 	if jsonError != nil {
@@ -48,13 +50,70 @@ func HandleV2(ctx *web.Context, post bool) {
 	ctx.Write([]byte(jsonResp.String()))
 }
 
-func HandleV2PostRequest(j *primitives.JSON2Request) (*primitives.JSON2Response, *primitives.JSONError) {
-	//params := j.Params
+func HandleV2PostRequest(ctx context.Context, j *primitives.JSON2Request) (*primitives.JSON2Response, *primitives.JSONError) {
+	params := j.Params
 	var resp interface{}
 	var jsonError *primitives.JSONError
-	/*
-		switch j.Method {
-		case "compose-chain-submit":
+
+	switch j.Method {
+	case "wallet-unlock":
+		resp, jsonError = HandleV2WalletUnlock(params)
+		break
+	case "wallet-lock":
+		resp, jsonError = HandleV2WalletLock(params)
+		break
+	case "wallet-change-passphrase":
+		resp, jsonError = HandleV2WalletChangePassphrase(params)
+		break
+	case "wallet-export-mnemonic":
+		resp, jsonError = HandleV2WalletExportMnemonic(params)
+		break
+	case "wallet-new-mnemonic":
+		resp, jsonError = HandleV2WalletNewMnemonic(params)
+		break
+	case "wallet-import-mnemonic":
+		resp, jsonError = HandleV2WalletImportMnemonic(ctx, params)
+		break
+	case "wallet-discover-addresses":
+		resp, jsonError = HandleV2WalletDiscoverAddresses(ctx, params)
+		break
+	case "factoid-generate-multisig-address":
+		resp, jsonError = HandleV2FactoidGenerateMultisigAddress(params)
+		break
+	case "import-watch-only-address":
+		resp, jsonError = HandleV2ImportWatchOnlyAddress(params)
+		break
+	case "factoid-export-transaction":
+		resp, jsonError = HandleV2FactoidExportTransaction(params)
+		break
+	case "factoid-import-transaction":
+		resp, jsonError = HandleV2FactoidImportTransaction(params)
+		break
+	case "factoid-cosign-transaction":
+		resp, jsonError = HandleV2FactoidCosignTransaction(params)
+		break
+	case "factoid-merge-transaction":
+		resp, jsonError = HandleV2FactoidMergeTransaction(params)
+		break
+	case "factoid-submit-transaction":
+		resp, jsonError = HandleV2FactoidSubmitTransaction(ctx, params)
+		break
+	case "send":
+		resp, jsonError = HandleV2Send(ctx, params)
+		break
+	case "sweep":
+		resp, jsonError = HandleV2Sweep(ctx, params)
+		break
+	case "batch-pay":
+		resp, jsonError = HandleV2BatchPay(ctx, params)
+		break
+	case "buy-ec":
+		resp, jsonError = HandleV2BuyEC(ctx, params)
+		break
+	case "set-change-address":
+		resp, jsonError = HandleV2SetChangeAddress(params)
+		break
+		/*case "compose-chain-submit":
 			resp, jsonError = HandleV2ComposeChainSubmit(params)
 			break
 		case "compose-entry-submit":
@@ -95,9 +154,9 @@ func HandleV2PostRequest(j *primitives.JSON2Request) (*primitives.JSON2Response,
 			break
 		case "factoid-get-processed-transactionsj/":
 			resp, jsonError = HandleV2GetProcessedTransactionsj(params)
-			break
-		}
-	*/
+			break*/
+	}
+
 	if jsonError != nil {
 		return nil, jsonError
 	}
@@ -109,17 +168,23 @@ func HandleV2PostRequest(j *primitives.JSON2Request) (*primitives.JSON2Response,
 	return jsonResp, nil
 }
 
-func HandleV2GetRequest(j *primitives.JSON2Request) (*primitives.JSON2Response, *primitives.JSONError) {
+func HandleV2GetRequest(ctx context.Context, j *primitives.JSON2Request) (*primitives.JSON2Response, *primitives.JSONError) {
 	params := j.Params
 	var resp interface{}
 	var jsonError *primitives.JSONError
 
 	switch j.Method {
 	case "factoid-balance":
-		resp, jsonError = HandleV2FactoidBalance(params)
+		resp, jsonError = HandleV2FactoidBalance(ctx, params)
 		break
 	case "entry-credit-balance":
-		resp, jsonError = HandleV2EntryCreditBalance(params)
+		resp, jsonError = HandleV2EntryCreditBalance(ctx, params)
+		break
+	case "get-change-address":
+		resp, jsonError = HandleV2GetChangeAddress(params)
+		break
+	case "transaction-history":
+		resp, jsonError = HandleV2History(params)
 		break
 		/*case "factoid-generate-address":
 			resp, jsonError = HandleV2FactoidGenerateAddress(params)
@@ -181,3 +246,13 @@ func HandleV2GetRequest(j *primitives.JSON2Request) (*primitives.JSON2Response,
 
 	return jsonResp, nil
 }
+
+// mapToStruct fills in the given struct from JSON 2.0 params, which arrive
+// as a generic map.
+func mapToStruct(params interface{}, dst interface{}) error {
+	b, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, dst)
+}
@@ -6,6 +6,8 @@ package handlers
 
 import (
 	"github.com/FactomProject/factomd/common/primitives"
+
+	"github.com/FactomProject/fctwallet2/Wallet"
 )
 
 func NewInvalidNameError() *primitives.JSONError {
@@ -35,3 +37,148 @@ type VerifyAddressTypeResponse struct {
 	Type  string
 	Valid bool
 }
+
+//Lock
+
+type WalletUnlockRequest struct {
+	Passphrase string
+	Timeout    int // seconds
+}
+
+type ChangePassphraseRequest struct {
+	Old string
+	New string
+}
+
+type WalletLockResponse struct {
+	Locked        bool
+	UnlockedUntil string
+}
+
+//Mnemonic
+
+type NewMnemonicRequest struct {
+	Words int
+}
+
+type ImportMnemonicRequest struct {
+	Mnemonic     string
+	FctAddresses int
+	ECAddresses  int
+	GapLimit     int
+}
+
+type DiscoverAddressesRequest struct {
+	GapLimit int
+}
+
+type MnemonicResponse struct {
+	Mnemonic string
+}
+
+type ImportMnemonicResponse struct {
+	Addresses []string
+}
+
+//Multisig
+
+type GenerateMultisigAddressRequest struct {
+	Name string
+	M    int
+	Keys []string
+}
+
+type GenerateMultisigAddressResponse struct {
+	Address string
+	Warning string
+}
+
+//Partially signed transactions
+
+type PartialTransactionRequest struct {
+	Key         string
+	Transaction string
+}
+
+type PartialTransactionResponse struct {
+	Transaction string
+}
+
+type SignaturesResponse struct {
+	Complete bool
+}
+
+//Watch-only
+
+type ImportWatchOnlyRequest struct {
+	Name    string
+	Address string
+	Type    string
+}
+
+//Send
+
+type SendRequest struct {
+	Outputs []Wallet.Payment
+}
+
+type SendResponse struct {
+	TxID string
+	Fee  uint64
+}
+
+type ChangeAddressRequest struct {
+	Name string
+}
+
+type ChangeAddressResponse struct {
+	Name string
+}
+
+//Sweep
+
+type SweepRequest struct {
+	From string
+	To   string
+}
+
+type SweepResponse struct {
+	TxID   string
+	Amount uint64
+	Fee    uint64
+}
+
+//Batch payouts
+
+type BatchPayRequest struct {
+	From   string
+	Rows   []Wallet.BatchRow
+	DryRun bool
+}
+
+//History
+
+// HistoryRequest asks for a page of the history of the addresses, or of
+// every address in the wallet if none are given.  Times are in
+// milliseconds, and zero values don't filter.  After is the Next cursor of
+// the page before.
+type HistoryRequest struct {
+	Addresses  []string
+	FromHeight uint32
+	ToHeight   uint32
+	FromTime   uint64
+	ToTime     uint64
+	Directions []string // in, out, ec or commit
+	MinAmount  uint64
+	After      string
+	Limit      int
+}
+
+//Entry credits
+
+type BuyECRequest struct {
+	From    string
+	To      string
+	Credits uint64
+	Wait    bool
+}
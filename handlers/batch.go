@@ -0,0 +1,118 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/wsapi"
+	"github.com/FactomProject/web"
+
+	"github.com/FactomProject/fctwallet2/Wallet"
+)
+
+// &from=<name or address>&dryrun=<true or false>
+// The body holds the rows, either as CSV lines of address,amount or as a
+// JSON list of {"Address":..., "Amount":...}.  Amounts are in factoshis.
+func HandleFactoidBatchPay(ctx *web.Context, params string) {
+	req := new(BatchPayRequest)
+	req.From = ctx.Params["from"]
+	req.DryRun = ctx.Params["dryrun"] == "true"
+
+	body, err := ioutil.ReadAll(ctx.Request.Body)
+	if err != nil {
+		reportResults(ctx, err.Error(), false)
+		return
+	}
+	req.Rows, err = parseBatchRows(body)
+	if err != nil {
+		reportResults(ctx, err.Error(), false)
+		return
+	}
+
+	result, err := Wallet.PayBatch(ctx.Request.Context(), req.From, req.Rows, req.DryRun)
+	if result == nil {
+		reportResults(ctx, err.Error(), false)
+		return
+	}
+
+	var out bytes.Buffer
+	for _, row := range result.Rows {
+		status := row.TxID
+		if len(row.Error) > 0 {
+			status = row.Error
+		}
+		out.WriteString(fmt.Sprintf("%s %16s %s\n", row.Address, primitives.ConvertDecimalToString(row.Amount), status))
+	}
+	out.WriteString(fmt.Sprintf("\nTotal:   %s\nFees:    %s\nBalance: %s\n",
+		primitives.ConvertDecimalToString(result.Total),
+		primitives.ConvertDecimalToString(result.Fees),
+		primitives.ConvertDecimalToString(result.Balance)))
+	if err != nil {
+		out.WriteString(err.Error())
+	} else if result.DryRun {
+		out.WriteString("Dry run. Nothing was signed or submitted")
+	}
+	reportResults(ctx, out.String(), err == nil)
+}
+
+func parseBatchRows(body []byte) ([]Wallet.BatchRow, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var rows []Wallet.BatchRow
+		if err := json.Unmarshal(body, &rows); err != nil {
+			return nil, fmt.Errorf("Error parsing rows: %v", err)
+		}
+		return rows, nil
+	}
+
+	var rows []Wallet.BatchRow
+	r := csv.NewReader(bytes.NewReader(body))
+	r.FieldsPerRecord = 2
+	r.TrimLeadingSpace = true
+	for line := 1; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing rows: %v", err)
+		}
+		amount, err := strconv.ParseUint(strings.TrimSpace(record[1]), 10, 64)
+		if err != nil {
+			if line == 1 {
+				continue // A header
+			}
+			return nil, fmt.Errorf("Error parsing the amount on line %d: %v", line, err)
+		}
+		rows = append(rows, Wallet.BatchRow{Address: record[0], Amount: amount})
+	}
+	return rows, nil
+}
+
+func HandleV2BatchPay(ctx context.Context, params interface{}) (interface{}, *primitives.JSONError) {
+	req := new(BatchPayRequest)
+	if err := mapToStruct(params, req); err != nil {
+		return nil, wsapi.NewInvalidParamsError()
+	}
+
+	result, err := Wallet.PayBatch(ctx, req.From, req.Rows, req.DryRun)
+	if err != nil {
+		if result != nil {
+			return nil, primitives.NewJSONError(-32603, err.Error(), result)
+		}
+		return nil, wsapi.NewCustomInternalError(err.Error())
+	}
+	return result, nil
+}
@@ -0,0 +1,40 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package handlers
+
+import (
+	"testing"
+)
+
+func TestParseBatchRows(t *testing.T) {
+	const fa = "FA2jK2HcLnRdS94dEcU27rF3meoJfpUcZPSinpb7AwQvPRY6RL1Q"
+	const ec = "EC2DKSYyRcNWf7RS963VFYgMExoHRYLHVeCfQ9PGPmNzwrcmgm2r"
+
+	for _, body := range []string{
+		"address,amount\n" + fa + ",100\n" + ec + ", 200\n",
+		fa + ",100\n" + ec + ",200",
+		`[{"Address": "` + fa + `", "Amount": 100}, {"Address": "` + ec + `", "Amount": 200}]`,
+	} {
+		rows, err := parseBatchRows([]byte(body))
+		if err != nil {
+			t.Fatalf("%q: %v", body, err)
+		}
+		if len(rows) != 2 || rows[0].Address != fa || rows[0].Amount != 100 ||
+			rows[1].Address != ec || rows[1].Amount != 200 {
+			t.Errorf("%q parsed as %+v", body, rows)
+		}
+	}
+
+	// Only the first line can be a header.
+	for _, body := range []string{
+		fa + ",100\naddress,amount\n",
+		fa + ",100," + ec + "\n",
+		`[{"Address": "` + fa + `", "Amount": "100"}]`,
+	} {
+		if rows, err := parseBatchRows([]byte(body)); err == nil {
+			t.Errorf("%q parsed as %+v", body, rows)
+		}
+	}
+}
@@ -21,7 +21,7 @@ func HandleCommitChain(ctx *web.Context, name string) {
 		return
 	}
 
-	err = Wallet.CommitChain(name, data)
+	err = Wallet.CommitChain(ctx.Request.Context(), name, data)
 	if err != nil {
 		fmt.Println(err)
 		ctx.WriteHeader(httpBad)
@@ -39,7 +39,7 @@ func HandleCommitEntry(ctx *web.Context, name string) {
 		return
 	}
 
-	err = Wallet.CommitEntry(name, data)
+	err = Wallet.CommitEntry(ctx.Request.Context(), name, data)
 	if err != nil {
 		fmt.Println(err)
 		ctx.WriteHeader(httpBad)
@@ -0,0 +1,130 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/wsapi"
+	"github.com/FactomProject/web"
+
+	"github.com/FactomProject/fctwallet2/Wallet"
+)
+
+// postParam returns a value posted in the form body of the request.
+// Passphrases and mnemonics are only read from there, as query strings end
+// up in proxy and access logs, and in shell history.
+func postParam(ctx *web.Context, name string) string {
+	return ctx.Request.PostFormValue(name)
+}
+
+// Posted: passphrase=<passphrase>
+func HandleWalletEncrypt(ctx *web.Context, params string) {
+	passphrase := postParam(ctx, "passphrase")
+
+	err := Wallet.EncryptWallet(passphrase)
+	if err != nil {
+		reportResults(ctx, err.Error(), false)
+		return
+	}
+
+	reportResults(ctx, "Wallet encrypted and locked", true)
+}
+
+// &timeout=<seconds>
+// Posted: passphrase=<passphrase>
+func HandleWalletUnlock(ctx *web.Context, params string) {
+	req := new(WalletUnlockRequest)
+	req.Passphrase = postParam(ctx, "passphrase")
+	if t := ctx.Params["timeout"]; len(t) > 0 {
+		timeout, err := strconv.Atoi(t)
+		if err != nil {
+			reportResults(ctx, fmt.Sprintf("Error parsing timeout: %v", err), false)
+			return
+		}
+		req.Timeout = timeout
+	}
+
+	jsonResp, jsonError := HandleV2PostRequest(ctx.Request.Context(), primitives.NewJSON2Request(1, req, "wallet-unlock"))
+	if jsonError != nil {
+		reportResults(ctx, jsonError.Message, false)
+		return
+	}
+
+	until := jsonResp.Result.(*WalletLockResponse).UnlockedUntil
+	reportResults(ctx, fmt.Sprintf("Wallet unlocked until %s", until), true)
+}
+
+func HandleWalletLock(ctx *web.Context, params string) {
+	_, jsonError := HandleV2PostRequest(ctx.Request.Context(), primitives.NewJSON2Request(1, nil, "wallet-lock"))
+	if jsonError != nil {
+		reportResults(ctx, jsonError.Message, false)
+		return
+	}
+
+	reportResults(ctx, "Wallet locked", true)
+}
+
+// Posted: old=<passphrase>&new=<passphrase>
+func HandleWalletChangePassphrase(ctx *web.Context, params string) {
+	req := new(ChangePassphraseRequest)
+	req.Old = postParam(ctx, "old")
+	req.New = postParam(ctx, "new")
+
+	_, jsonError := HandleV2PostRequest(ctx.Request.Context(), primitives.NewJSON2Request(1, req, "wallet-change-passphrase"))
+	if jsonError != nil {
+		reportResults(ctx, jsonError.Message, false)
+		return
+	}
+
+	reportResults(ctx, "Passphrase changed", true)
+}
+
+func HandleV2WalletUnlock(params interface{}) (interface{}, *primitives.JSONError) {
+	req := new(WalletUnlockRequest)
+	if err := mapToStruct(params, req); err != nil {
+		return nil, wsapi.NewInvalidParamsError()
+	}
+
+	until, err := Wallet.UnlockWallet(req.Passphrase, req.Timeout)
+	if err != nil {
+		return nil, wsapi.NewCustomInternalError(err.Error())
+	}
+
+	resp := new(WalletLockResponse)
+	resp.Locked = false
+	resp.UnlockedUntil = until.Format(time.RFC3339)
+	return resp, nil
+}
+
+func HandleV2WalletLock(params interface{}) (interface{}, *primitives.JSONError) {
+	Wallet.LockWallet()
+
+	resp := new(WalletLockResponse)
+	resp.Locked = true
+	return resp, nil
+}
+
+func HandleV2WalletChangePassphrase(params interface{}) (interface{}, *primitives.JSONError) {
+	req := new(ChangePassphraseRequest)
+	if err := mapToStruct(params, req); err != nil {
+		return nil, wsapi.NewInvalidParamsError()
+	}
+
+	if err := Wallet.ChangePassphrase(req.Old, req.New); err != nil {
+		return nil, wsapi.NewCustomInternalError(err.Error())
+	}
+
+	locked, err := Wallet.IsLocked()
+	if err != nil {
+		return nil, wsapi.NewCustomInternalError(err.Error())
+	}
+	resp := new(WalletLockResponse)
+	resp.Locked = locked
+	return resp, nil
+}
@@ -5,6 +5,10 @@
 package handlers
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+
 	"github.com/FactomProject/factomd/common/primitives"
 	"github.com/FactomProject/factomd/wsapi"
 	"github.com/FactomProject/fctwallet2/Wallet"
@@ -203,3 +207,93 @@ func HandleV2VerifyAddressType(params interface{}) (interface{}, *primitives.JSO
 	answer.Valid = pass
 	return answer, nil
 }
+
+/*********************************************************************************************************/
+/*********************************************Multisig****************************************************/
+/*********************************************************************************************************/
+
+// &name=<name>&m=<signatures required>&keys=<name or public key>,<name or public key>...
+func HandleFactoidGenerateMultisigAddress(ctx *web.Context, params string) {
+	req := new(GenerateMultisigAddressRequest)
+	req.Name = ctx.Params["name"]
+	m, err := strconv.Atoi(ctx.Params["m"])
+	if err != nil {
+		reportResults(ctx, fmt.Sprintf("Error parsing m: %v", err), false)
+		return
+	}
+	req.M = m
+	if keys := ctx.Params["keys"]; len(keys) > 0 {
+		req.Keys = strings.Split(keys, ",")
+	}
+
+	jsonResp, jsonError := HandleV2PostRequest(ctx.Request.Context(), primitives.NewJSON2Request(1, req, "factoid-generate-multisig-address"))
+	if jsonError != nil {
+		reportResults(ctx, jsonError.Message, false)
+		return
+	}
+
+	reportResults(ctx, jsonResp.Result.(*GenerateMultisigAddressResponse).Address, true)
+}
+
+func HandleV2FactoidGenerateMultisigAddress(params interface{}) (interface{}, *primitives.JSONError) {
+	req := new(GenerateMultisigAddressRequest)
+	if err := mapToStruct(params, req); err != nil {
+		return nil, wsapi.NewInvalidParamsError()
+	}
+
+	if Utility.IsValidKey(req.Name) == false {
+		return nil, NewInvalidNameError()
+	}
+
+	adrstr, err := Wallet.GenerateMultisigAddressString(req.Name, req.M, req.Keys)
+	if err != nil {
+		return nil, wsapi.NewCustomInternalError(err.Error())
+	}
+
+	resp := new(GenerateMultisigAddressResponse)
+	resp.Address = adrstr
+	resp.Warning = Wallet.ErrMultisigUnsupported.Error()
+
+	return resp, nil
+}
+
+/*********************************************************************************************************/
+/*********************************************Watch-only**************************************************/
+/*********************************************************************************************************/
+
+// &name=<name>&address=<FA or EC address, or public key>&type=<fct or ec>
+func HandleImportWatchOnlyAddress(ctx *web.Context, params string) {
+	req := new(ImportWatchOnlyRequest)
+	req.Name = ctx.Params["name"]
+	req.Address = ctx.Params["address"]
+	req.Type = ctx.Params["type"]
+
+	jsonResp, jsonError := HandleV2PostRequest(ctx.Request.Context(), primitives.NewJSON2Request(1, req, "import-watch-only-address"))
+	if jsonError != nil {
+		reportResults(ctx, jsonError.Message, false)
+		return
+	}
+
+	reportResults(ctx, jsonResp.Result.(*GenerateAddressResponse).Address, true)
+}
+
+func HandleV2ImportWatchOnlyAddress(params interface{}) (interface{}, *primitives.JSONError) {
+	req := new(ImportWatchOnlyRequest)
+	if err := mapToStruct(params, req); err != nil {
+		return nil, wsapi.NewInvalidParamsError()
+	}
+
+	if Utility.IsValidKey(req.Name) == false {
+		return nil, NewInvalidNameError()
+	}
+
+	adrstr, err := Wallet.ImportWatchOnlyString(req.Name, req.Address, req.Type)
+	if err != nil {
+		return nil, wsapi.NewCustomInternalError(err.Error())
+	}
+
+	resp := new(GenerateAddressResponse)
+	resp.Address = adrstr
+
+	return resp, nil
+}
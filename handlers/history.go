@@ -0,0 +1,93 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/wsapi"
+	"github.com/FactomProject/web"
+
+	"github.com/FactomProject/fctwallet2/Wallet"
+	"github.com/FactomProject/fctwallet2/Wallet/Utility"
+)
+
+// &addresses=<name or address>,<name or address>...
+// &fromheight=<height>&toheight=<height>&fromtime=<ms>&totime=<ms>
+// &directions=<in,out,ec,commit>&minamount=<amount>&after=<cursor>&limit=<count>
+// Every parameter is optional.  The page is written as JSON.
+func HandleGetHistory(ctx *web.Context, params string) {
+	req := new(HistoryRequest)
+	if a := ctx.Params["addresses"]; len(a) > 0 {
+		req.Addresses = strings.Split(a, ",")
+	}
+	if d := ctx.Params["directions"]; len(d) > 0 {
+		req.Directions = strings.Split(d, ",")
+	}
+	req.After = ctx.Params["after"]
+
+	nums := []struct {
+		name string
+		bits int
+		set  func(uint64)
+	}{
+		{"fromheight", 32, func(v uint64) { req.FromHeight = uint32(v) }},
+		{"toheight", 32, func(v uint64) { req.ToHeight = uint32(v) }},
+		{"fromtime", 64, func(v uint64) { req.FromTime = v }},
+		{"totime", 64, func(v uint64) { req.ToTime = v }},
+		{"minamount", 64, func(v uint64) { req.MinAmount = v }},
+		{"limit", 16, func(v uint64) { req.Limit = int(v) }},
+	}
+	for _, n := range nums {
+		s := ctx.Params[n.name]
+		if len(s) == 0 {
+			continue
+		}
+		v, err := strconv.ParseUint(s, 10, n.bits)
+		if err != nil {
+			reportResults(ctx, fmt.Sprintf("Error parsing %s: %v", n.name, err), false)
+			return
+		}
+		n.set(v)
+	}
+
+	jsonResp, jsonError := HandleV2GetRequest(ctx.Request.Context(), primitives.NewJSON2Request(1, req, "transaction-history"))
+	if jsonError != nil {
+		reportResults(ctx, jsonError.Message, false)
+		return
+	}
+	j, err := json.Marshal(jsonResp.Result)
+	if err != nil {
+		reportResults(ctx, err.Error(), false)
+		return
+	}
+	ctx.ContentType("json")
+	ctx.Write(j)
+}
+
+func HandleV2History(params interface{}) (interface{}, *primitives.JSONError) {
+	req := new(HistoryRequest)
+	if err := mapToStruct(params, req); err != nil {
+		return nil, wsapi.NewInvalidParamsError()
+	}
+
+	filter := Utility.HistoryFilter{
+		FromHeight: req.FromHeight,
+		ToHeight:   req.ToHeight,
+		FromTime:   req.FromTime,
+		ToTime:     req.ToTime,
+		Directions: req.Directions,
+		MinAmount:  req.MinAmount,
+	}
+	page, err := Wallet.History(req.Addresses, filter, req.After, req.Limit)
+	if err != nil {
+		return nil, wsapi.NewCustomInternalError(err.Error())
+	}
+	return page, nil
+}
@@ -0,0 +1,156 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/wsapi"
+	"github.com/FactomProject/web"
+
+	"github.com/FactomProject/fctwallet2/Wallet"
+)
+
+func HandleWalletExportMnemonic(ctx *web.Context, params string) {
+	jsonResp, jsonError := HandleV2PostRequest(ctx.Request.Context(), primitives.NewJSON2Request(1, nil, "wallet-export-mnemonic"))
+	if jsonError != nil {
+		reportResults(ctx, jsonError.Message, false)
+		return
+	}
+
+	reportResults(ctx, jsonResp.Result.(*MnemonicResponse).Mnemonic, true)
+}
+
+// &words=<12 or 24>
+func HandleWalletNewMnemonic(ctx *web.Context, params string) {
+	req := new(NewMnemonicRequest)
+	if w := ctx.Params["words"]; len(w) > 0 {
+		words, err := strconv.Atoi(w)
+		if err != nil {
+			reportResults(ctx, fmt.Sprintf("Error parsing words: %v", err), false)
+			return
+		}
+		req.Words = words
+	}
+
+	jsonResp, jsonError := HandleV2PostRequest(ctx.Request.Context(), primitives.NewJSON2Request(1, req, "wallet-new-mnemonic"))
+	if jsonError != nil {
+		reportResults(ctx, jsonError.Message, false)
+		return
+	}
+
+	reportResults(ctx, jsonResp.Result.(*MnemonicResponse).Mnemonic, true)
+}
+
+// &fct=<count>&ec=<count>&gap=<count>
+// Posted: mnemonic=<words>
+func HandleWalletImportMnemonic(ctx *web.Context, params string) {
+	req := new(ImportMnemonicRequest)
+	req.Mnemonic = postParam(ctx, "mnemonic")
+	for name, count := range map[string]*int{"fct": &req.FctAddresses, "ec": &req.ECAddresses, "gap": &req.GapLimit} {
+		if c := ctx.Params[name]; len(c) > 0 {
+			n, err := strconv.Atoi(c)
+			if err != nil {
+				reportResults(ctx, fmt.Sprintf("Error parsing %s: %v", name, err), false)
+				return
+			}
+			*count = n
+		}
+	}
+
+	jsonResp, jsonError := HandleV2PostRequest(ctx.Request.Context(), primitives.NewJSON2Request(1, req, "wallet-import-mnemonic"))
+	if jsonError != nil {
+		reportResults(ctx, jsonError.Message, false)
+		return
+	}
+
+	addresses := jsonResp.Result.(*ImportMnemonicResponse).Addresses
+	reportResults(ctx, strings.Join(addresses, "\n"), true)
+}
+
+// &gap=<count>
+func HandleWalletDiscoverAddresses(ctx *web.Context, params string) {
+	req := new(DiscoverAddressesRequest)
+	if g := ctx.Params["gap"]; len(g) > 0 {
+		gap, err := strconv.Atoi(g)
+		if err != nil {
+			reportResults(ctx, fmt.Sprintf("Error parsing gap: %v", err), false)
+			return
+		}
+		req.GapLimit = gap
+	}
+
+	jsonResp, jsonError := HandleV2PostRequest(ctx.Request.Context(), primitives.NewJSON2Request(1, req, "wallet-discover-addresses"))
+	if jsonError != nil {
+		reportResults(ctx, jsonError.Message, false)
+		return
+	}
+
+	addresses := jsonResp.Result.(*ImportMnemonicResponse).Addresses
+	reportResults(ctx, strings.Join(addresses, "\n"), true)
+}
+
+func HandleV2WalletExportMnemonic(params interface{}) (interface{}, *primitives.JSONError) {
+	mnemonic, err := Wallet.ExportMnemonic()
+	if err != nil {
+		return nil, wsapi.NewCustomInternalError(err.Error())
+	}
+
+	resp := new(MnemonicResponse)
+	resp.Mnemonic = mnemonic
+	return resp, nil
+}
+
+func HandleV2WalletNewMnemonic(params interface{}) (interface{}, *primitives.JSONError) {
+	req := new(NewMnemonicRequest)
+	if err := mapToStruct(params, req); err != nil {
+		return nil, wsapi.NewInvalidParamsError()
+	}
+
+	mnemonic, err := Wallet.NewMnemonic(req.Words)
+	if err != nil {
+		return nil, wsapi.NewCustomInternalError(err.Error())
+	}
+
+	resp := new(MnemonicResponse)
+	resp.Mnemonic = mnemonic
+	return resp, nil
+}
+
+func HandleV2WalletImportMnemonic(ctx context.Context, params interface{}) (interface{}, *primitives.JSONError) {
+	req := new(ImportMnemonicRequest)
+	if err := mapToStruct(params, req); err != nil {
+		return nil, wsapi.NewInvalidParamsError()
+	}
+
+	addresses, err := Wallet.ImportMnemonic(ctx, req.Mnemonic, req.FctAddresses, req.ECAddresses, req.GapLimit)
+	if err != nil {
+		return nil, wsapi.NewCustomInternalError(err.Error())
+	}
+
+	resp := new(ImportMnemonicResponse)
+	resp.Addresses = addresses
+	return resp, nil
+}
+
+func HandleV2WalletDiscoverAddresses(ctx context.Context, params interface{}) (interface{}, *primitives.JSONError) {
+	req := new(DiscoverAddressesRequest)
+	if err := mapToStruct(params, req); err != nil {
+		return nil, wsapi.NewInvalidParamsError()
+	}
+
+	addresses, err := Wallet.DiscoverAddresses(ctx, req.GapLimit)
+	if err != nil {
+		return nil, wsapi.NewCustomInternalError(err.Error())
+	}
+
+	resp := new(ImportMnemonicResponse)
+	resp.Addresses = addresses
+	return resp, nil
+}
@@ -0,0 +1,166 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package handlers
+
+import (
+	"context"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/wsapi"
+	"github.com/FactomProject/web"
+
+	"github.com/FactomProject/fctwallet2/Wallet"
+)
+
+func HandleFactoidExportTransaction(ctx *web.Context, key string) {
+	jsonResp, jsonError := HandleV2PostRequest(ctx.Request.Context(), primitives.NewJSON2Request(1, key, "factoid-export-transaction"))
+	if jsonError != nil {
+		reportResults(ctx, jsonError.Message, false)
+		return
+	}
+
+	reportResults(ctx, jsonResp.Result.(*PartialTransactionResponse).Transaction, true)
+}
+
+// &key=<key>&transaction=<partially signed transaction>
+func HandleFactoidImportTransaction(ctx *web.Context, params string) {
+	req := new(PartialTransactionRequest)
+	req.Key = ctx.Params["key"]
+	req.Transaction = ctx.Params["transaction"]
+
+	_, jsonError := HandleV2PostRequest(ctx.Request.Context(), primitives.NewJSON2Request(1, req, "factoid-import-transaction"))
+	if jsonError != nil {
+		reportResults(ctx, jsonError.Message, false)
+		return
+	}
+
+	reportResults(ctx, "Success importing transaction", true)
+}
+
+func HandleFactoidCosignTransaction(ctx *web.Context, key string) {
+	jsonResp, jsonError := HandleV2PostRequest(ctx.Request.Context(), primitives.NewJSON2Request(1, key, "factoid-cosign-transaction"))
+	if jsonError != nil {
+		reportResults(ctx, jsonError.Message, false)
+		return
+	}
+
+	reportSignatures(ctx, jsonResp.Result.(*SignaturesResponse).Complete)
+}
+
+// &key=<key>&transaction=<partially signed transaction>
+func HandleFactoidMergeTransaction(ctx *web.Context, params string) {
+	req := new(PartialTransactionRequest)
+	req.Key = ctx.Params["key"]
+	req.Transaction = ctx.Params["transaction"]
+
+	jsonResp, jsonError := HandleV2PostRequest(ctx.Request.Context(), primitives.NewJSON2Request(1, req, "factoid-merge-transaction"))
+	if jsonError != nil {
+		reportResults(ctx, jsonError.Message, false)
+		return
+	}
+
+	reportSignatures(ctx, jsonResp.Result.(*SignaturesResponse).Complete)
+}
+
+func reportSignatures(ctx *web.Context, complete bool) {
+	if complete {
+		reportResults(ctx, "Transaction is fully signed", true)
+	} else {
+		reportResults(ctx, "Signatures added. More are needed before the transaction can be submitted", true)
+	}
+}
+
+func HandleV2FactoidExportTransaction(params interface{}) (interface{}, *primitives.JSONError) {
+	key, ok := params.(string)
+	if ok == false {
+		return nil, wsapi.NewInvalidParamsError()
+	}
+
+	pst, err := Wallet.ExportTransaction(key)
+	if err != nil {
+		return nil, wsapi.NewCustomInternalError(err.Error())
+	}
+
+	resp := new(PartialTransactionResponse)
+	resp.Transaction = pst
+	return resp, nil
+}
+
+func HandleV2FactoidImportTransaction(params interface{}) (interface{}, *primitives.JSONError) {
+	req := new(PartialTransactionRequest)
+	if err := mapToStruct(params, req); err != nil {
+		return nil, wsapi.NewInvalidParamsError()
+	}
+
+	err := Wallet.ImportTransaction(req.Key, req.Transaction)
+	if err != nil {
+		return nil, wsapi.NewCustomInternalError(err.Error())
+	}
+
+	resp := new(PartialTransactionResponse)
+	resp.Transaction = req.Transaction
+	return resp, nil
+}
+
+func HandleV2FactoidCosignTransaction(params interface{}) (interface{}, *primitives.JSONError) {
+	key, ok := params.(string)
+	if ok == false {
+		return nil, wsapi.NewInvalidParamsError()
+	}
+
+	complete, err := Wallet.CosignTransaction(key)
+	if err != nil {
+		return nil, wsapi.NewCustomInternalError(err.Error())
+	}
+
+	resp := new(SignaturesResponse)
+	resp.Complete = complete
+	return resp, nil
+}
+
+func HandleV2FactoidMergeTransaction(params interface{}) (interface{}, *primitives.JSONError) {
+	req := new(PartialTransactionRequest)
+	if err := mapToStruct(params, req); err != nil {
+		return nil, wsapi.NewInvalidParamsError()
+	}
+
+	complete, err := Wallet.MergeTransaction(req.Key, req.Transaction)
+	if err != nil {
+		return nil, wsapi.NewCustomInternalError(err.Error())
+	}
+
+	resp := new(SignaturesResponse)
+	resp.Complete = complete
+	return resp, nil
+}
+
+// &transaction=<signed transaction>
+func HandleFactoidSubmitTransaction(ctx *web.Context, params string) {
+	req := new(PartialTransactionRequest)
+	req.Transaction = ctx.Params["transaction"]
+
+	_, jsonError := HandleV2PostRequest(ctx.Request.Context(), primitives.NewJSON2Request(1, req, "factoid-submit-transaction"))
+	if jsonError != nil {
+		reportResults(ctx, jsonError.Message, false)
+		return
+	}
+
+	reportResults(ctx, "Success Submitting transaction", true)
+}
+
+func HandleV2FactoidSubmitTransaction(ctx context.Context, params interface{}) (interface{}, *primitives.JSONError) {
+	req := new(PartialTransactionRequest)
+	if err := mapToStruct(params, req); err != nil {
+		return nil, wsapi.NewInvalidParamsError()
+	}
+
+	err := Wallet.SubmitTransaction(ctx, req.Transaction)
+	if err != nil {
+		return nil, wsapi.NewCustomInternalError(err.Error())
+	}
+
+	resp := new(PartialTransactionResponse)
+	resp.Transaction = req.Transaction
+	return resp, nil
+}
@@ -0,0 +1,191 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/wsapi"
+	"github.com/FactomProject/web"
+
+	"github.com/FactomProject/fctwallet2/Wallet"
+)
+
+// &to=<name or address>,<name or address>...&amount=<amount>,<amount>...
+func HandleFactoidSend(ctx *web.Context, params string) {
+	to := strings.Split(ctx.Params["to"], ",")
+	amounts := strings.Split(ctx.Params["amount"], ",")
+	if len(ctx.Params["to"]) == 0 || len(to) != len(amounts) {
+		reportResults(ctx, "Give an amount for each address to send to", false)
+		return
+	}
+
+	req := new(SendRequest)
+	for i := range to {
+		amount, err := strconv.ParseUint(amounts[i], 10, 64)
+		if err != nil {
+			reportResults(ctx, fmt.Sprintf("Error parsing amount: %v", err), false)
+			return
+		}
+		req.Outputs = append(req.Outputs, Wallet.Payment{Address: to[i], Amount: amount})
+	}
+
+	jsonResp, jsonError := HandleV2PostRequest(ctx.Request.Context(), primitives.NewJSON2Request(1, req, "send"))
+	if jsonError != nil {
+		reportResults(ctx, jsonError.Message, false)
+		return
+	}
+
+	resp := jsonResp.Result.(*SendResponse)
+	reportResults(ctx, fmt.Sprintf("Sent in transaction %s with a fee of %s",
+		resp.TxID, primitives.ConvertDecimalToString(resp.Fee)), true)
+}
+
+func HandleV2Send(ctx context.Context, params interface{}) (interface{}, *primitives.JSONError) {
+	req := new(SendRequest)
+	if err := mapToStruct(params, req); err != nil {
+		return nil, wsapi.NewInvalidParamsError()
+	}
+
+	txid, fee, err := Wallet.Send(ctx, req.Outputs)
+	if err != nil {
+		return nil, wsapi.NewCustomInternalError(err.Error())
+	}
+
+	resp := new(SendResponse)
+	resp.TxID = txid
+	resp.Fee = fee
+	return resp, nil
+}
+
+// &name=<name>
+// With no name, a fresh change address is generated for each transaction.
+func HandleSetChangeAddress(ctx *web.Context, params string) {
+	req := new(ChangeAddressRequest)
+	req.Name = ctx.Params["name"]
+
+	_, jsonError := HandleV2PostRequest(ctx.Request.Context(), primitives.NewJSON2Request(1, req, "set-change-address"))
+	if jsonError != nil {
+		reportResults(ctx, jsonError.Message, false)
+		return
+	}
+
+	if len(req.Name) == 0 {
+		reportResults(ctx, "Change goes to a fresh address for each transaction", true)
+		return
+	}
+	reportResults(ctx, fmt.Sprintf("Change goes to %s", req.Name), true)
+}
+
+func HandleV2SetChangeAddress(params interface{}) (interface{}, *primitives.JSONError) {
+	req := new(ChangeAddressRequest)
+	if err := mapToStruct(params, req); err != nil {
+		return nil, wsapi.NewInvalidParamsError()
+	}
+
+	err := Wallet.SetChangeAddress(req.Name)
+	if err != nil {
+		return nil, wsapi.NewCustomInternalError(err.Error())
+	}
+
+	resp := new(ChangeAddressResponse)
+	resp.Name = req.Name
+	return resp, nil
+}
+
+func HandleV2GetChangeAddress(params interface{}) (interface{}, *primitives.JSONError) {
+	name, err := Wallet.GetChangeAddress()
+	if err != nil {
+		return nil, wsapi.NewCustomInternalError(err.Error())
+	}
+
+	resp := new(ChangeAddressResponse)
+	resp.Name = name
+	return resp, nil
+}
+
+// &from=<name, address or Fs private key>&to=<name or address>
+func HandleFactoidSweep(ctx *web.Context, params string) {
+	req := new(SweepRequest)
+	req.From = ctx.Params["from"]
+	req.To = ctx.Params["to"]
+
+	jsonResp, jsonError := HandleV2PostRequest(ctx.Request.Context(), primitives.NewJSON2Request(1, req, "sweep"))
+	if jsonError != nil {
+		reportResults(ctx, jsonError.Message, false)
+		return
+	}
+
+	resp := jsonResp.Result.(*SweepResponse)
+	reportResults(ctx, fmt.Sprintf("Swept %s in transaction %s with a fee of %s",
+		primitives.ConvertDecimalToString(resp.Amount), resp.TxID, primitives.ConvertDecimalToString(resp.Fee)), true)
+}
+
+func HandleV2Sweep(ctx context.Context, params interface{}) (interface{}, *primitives.JSONError) {
+	req := new(SweepRequest)
+	if err := mapToStruct(params, req); err != nil {
+		return nil, wsapi.NewInvalidParamsError()
+	}
+
+	txid, amount, fee, err := Wallet.Sweep(ctx, req.From, req.To)
+	if err != nil {
+		return nil, wsapi.NewCustomInternalError(err.Error())
+	}
+
+	resp := new(SweepResponse)
+	resp.TxID = txid
+	resp.Amount = amount
+	resp.Fee = fee
+	return resp, nil
+}
+
+// &from=<name or address>&to=<name or EC address>&credits=<count>&wait=<true or false>
+func HandleFactoidBuyEC(ctx *web.Context, params string) {
+	req := new(BuyECRequest)
+	req.From = ctx.Params["from"]
+	req.To = ctx.Params["to"]
+	credits, err := strconv.ParseUint(ctx.Params["credits"], 10, 64)
+	if err != nil {
+		reportResults(ctx, fmt.Sprintf("Error parsing credits: %v", err), false)
+		return
+	}
+	req.Credits = credits
+	req.Wait = ctx.Params["wait"] == "true"
+
+	jsonResp, jsonError := HandleV2PostRequest(ctx.Request.Context(), primitives.NewJSON2Request(1, req, "buy-ec"))
+	if jsonError != nil {
+		reportResults(ctx, jsonError.Message, false)
+		return
+	}
+
+	resp := jsonResp.Result.(*Wallet.ECPurchase)
+	msg := fmt.Sprintf("Bought %d entry credits for %s with a fee of %s in transaction %s\n",
+		req.Credits, primitives.ConvertDecimalToString(resp.Factoshis), primitives.ConvertDecimalToString(resp.Fee), resp.TxID)
+	if resp.Confirmed {
+		msg += fmt.Sprintf("Entry credit balance: %d", resp.Balance)
+	} else if !resp.Waited {
+		msg += fmt.Sprintf("Submitted. Entry credit balance so far: %d", resp.Balance)
+	} else {
+		msg += fmt.Sprintf("Not confirmed yet. Entry credit balance: %d", resp.Balance)
+	}
+	reportResults(ctx, msg, true)
+}
+
+func HandleV2BuyEC(ctx context.Context, params interface{}) (interface{}, *primitives.JSONError) {
+	req := new(BuyECRequest)
+	if err := mapToStruct(params, req); err != nil {
+		return nil, wsapi.NewInvalidParamsError()
+	}
+
+	purchase, err := Wallet.BuyEntryCredits(ctx, req.From, req.To, req.Credits, req.Wait)
+	if err != nil {
+		return nil, wsapi.NewCustomInternalError(err.Error())
+	}
+	return purchase, nil
+}
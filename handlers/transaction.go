@@ -6,6 +6,7 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -345,7 +346,7 @@ func HandleFactoidDeleteTransaction(ctx *web.Context, key string) {
 }
 
 func HandleProperties(ctx *web.Context) {
-	p, f, w, err := Wallet.GetProperties()
+	p, f, w, err := Wallet.GetProperties(ctx.Request.Context())
 	if err != nil {
 		reportResults(ctx, "Failed to retrieve properties", false)
 		return
@@ -354,11 +355,31 @@ func HandleProperties(ctx *web.Context) {
 	ret := fmt.Sprintf("Protocol Version:   %s\n", p)
 	ret = ret + fmt.Sprintf("factomd Version:    %s\n", f)
 	ret = ret + fmt.Sprintf("fctwallet Version:  %s\n", w)
+	if n := Wallet.CurrentNode(); n != "" {
+		ret = ret + fmt.Sprintf("factomd Node:       %s\n", n)
+	}
+	ret = ret + fmt.Sprintf("Block Sync:         %s\n", syncStatus())
 
 	reportResults(ctx, ret, true)
 
 }
 
+// syncStatus describes how the sync of the blocks from factomd is going.
+func syncStatus() string {
+	s := Utility.GetSyncStatus()
+	var str string
+	switch s.State {
+	case Utility.SyncStateError:
+		str = "error: " + s.LastError
+	default:
+		str = s.State
+	}
+	if s.Synced {
+		str = str + fmt.Sprintf(" (height %d)", s.Height)
+	}
+	return str
+}
+
 func HandleFactoidAddFee(ctx *web.Context, params string) {
 	trans, key, _, address, _, ok := getParams_(ctx, params, false)
 	if !ok {
@@ -387,9 +408,9 @@ func HandleFactoidAddFee(ctx *web.Context, params string) {
 		return
 	}
 
-	if ins != outs+ecs {
+	if ins < outs+ecs {
 		msg := fmt.Sprintf(
-			"Addfee requires that all the inputs balance the outputs.\n"+
+			"Addfee requires that the inputs cover the outputs.\n"+
 				"The total inputs of your transaction are              %s\n"+
 				"The total outputs + ecoutputs of your transaction are %s",
 			primitives.ConvertDecimalToPaddedString(ins), primitives.ConvertDecimalToPaddedString(outs+ecs))
@@ -399,7 +420,18 @@ func HandleFactoidAddFee(ctx *web.Context, params string) {
 		return
 	}
 
-	transfee, err := Wallet.FactoidAddFee(trans, key, address, name)
+	var transfee uint64
+	if r := ctx.Params["rate"]; len(r) > 0 {
+		// Offline wallets must be told the fee rate
+		rate, err := strconv.ParseUint(r, 10, 64)
+		if err != nil {
+			reportResults(ctx, fmt.Sprintf("Error parsing rate: %v", err), false)
+			return
+		}
+		transfee, err = Wallet.FactoidAddFeeAtRate(trans, key, address, name, rate)
+	} else {
+		transfee, err = Wallet.FactoidAddFee(ctx.Request.Context(), trans, key, address, name)
+	}
 	if err != nil {
 		reportResults(ctx, err.Error(), false)
 		return
@@ -466,7 +498,7 @@ func HandleFactoidSignTransaction(ctx *web.Context, key string) {
 }
 
 func HandleFactoidSubmit(ctx *web.Context, jsonkey string) {
-	_, err := Wallet.FactoidSubmit(jsonkey)
+	_, err := Wallet.FactoidSubmit(ctx.Request.Context(), jsonkey)
 	if err != nil {
 		reportResults(ctx, err.Error(), false)
 		return
@@ -476,7 +508,7 @@ func HandleFactoidSubmit(ctx *web.Context, jsonkey string) {
 }
 
 func GetFee(ctx *web.Context) (int64, error) {
-	return Wallet.GetFee()
+	return Wallet.GetFee(ctx.Request.Context())
 }
 
 func HandleGetFee(ctx *web.Context, k string) {
@@ -496,10 +528,19 @@ func HandleGetFee(ctx *web.Context, k string) {
 		}
 	}
 
-	fee, err := Wallet.GetFee()
-	if err != nil {
-		reportResults(ctx, err.Error(), false)
-		return
+	var fee int64
+	if r := ctx.Params["rate"]; len(r) > 0 {
+		fee, err = strconv.ParseInt(r, 10, 64)
+		if err != nil {
+			reportResults(ctx, fmt.Sprintf("Error parsing rate: %v", err), false)
+			return
+		}
+	} else {
+		fee, err = Wallet.GetFee(ctx.Request.Context())
+		if err != nil {
+			reportResults(ctx, err.Error(), false)
+			return
+		}
 	}
 
 	if trans != nil {
@@ -510,7 +551,36 @@ func HandleGetFee(ctx *web.Context, k string) {
 	reportResults(ctx, fmt.Sprintf("%s", primitives.ConvertDecimalToString(uint64(fee))), true)
 }
 
-func GetAddresses() []byte {
+// addressBalance returns the balance of the FA or EC address computed from
+// the synced blocks, or the one factomd reports if the wallet is not yet
+// synced.  With check, factomd's balance is returned as well, otherwise
+// the balance is returned twice.  If the check fails, its error is
+// returned with the balance there is.
+func addressBalance(ctx context.Context, adrType string, adr string, check bool) (int64, int64, error) {
+	var cerr error
+	if check {
+		c, err := Wallet.CheckBalance(ctx, adrType, adr)
+		if err == nil {
+			return c.Local, c.Node, nil
+		}
+		cerr = err
+	}
+	local, node := Wallet.LocalFactoidBalance, FctBalance
+	if adrType == "EC" {
+		local, node = Wallet.LocalECBalance, ECBalance
+	}
+	bal, err := local(adr)
+	if err != nil {
+		bal, _ = node(ctx, adr)
+	}
+	return bal, bal, cerr
+}
+
+// GetAddresses lists the wallet's addresses with their balances, computed
+// from the synced blocks.  With check, each balance is compared with the
+// one factomd reports, and factomd's is shown next to any that differ, or
+// why it couldn't be checked.
+func GetAddresses(ctx context.Context, check bool) []byte {
 	values, err := Wallet.GetAddresses()
 	if err != nil {
 		panic(err)
@@ -520,13 +590,23 @@ func GetAddresses() []byte {
 	fctKeys := make([]string, 0, len(values))
 	ecBalances := make([]string, 0, len(values))
 	fctBalances := make([]string, 0, len(values))
+	ecNotes := make([]string, 0, len(values))
+	fctNotes := make([]string, 0, len(values))
 	fctAddresses := make([]string, 0, len(values))
 	ecAddresses := make([]string, 0, len(values))
+	changeKeys := make([]string, 0, len(values))
+	changeBalances := make([]string, 0, len(values))
+	changeNotes := make([]string, 0, len(values))
+	changeAddresses := make([]string, 0, len(values))
 
 	var maxlen int
 	for _, we := range values {
-		if len(we.GetName()) > maxlen {
-			maxlen = len(we.GetName())
+		name := string(we.GetName())
+		if Wallet.IsWatchOnly(we) {
+			name += " (watch-only)"
+		}
+		if len(name) > maxlen {
+			maxlen = len(name)
 		}
 		var adr string
 		if we.GetType() == "ec" {
@@ -536,36 +616,64 @@ func GetAddresses() []byte {
 			}
 			adr = primitives.ConvertECAddressToUserStr(address)
 			ecAddresses = append(ecAddresses, adr)
-			ecKeys = append(ecKeys, string(we.GetName()))
-			bal, _ := ECBalance(adr)
+			ecKeys = append(ecKeys, name)
+			bal, nodeBal, err := addressBalance(ctx, "EC", adr, check)
 			ecBalances = append(ecBalances, strconv.FormatInt(bal, 10))
+			var note string
+			if err != nil {
+				note = "   check failed: " + err.Error()
+			} else if nodeBal != bal {
+				note = "   factomd: " + strconv.FormatInt(nodeBal, 10)
+			}
+			ecNotes = append(ecNotes, note)
 		} else {
 			address, err := we.GetAddress()
 			if err != nil {
 				continue
 			}
 			adr = primitives.ConvertFctAddressToUserStr(address)
-			fctAddresses = append(fctAddresses, adr)
-			fctKeys = append(fctKeys, string(we.GetName()))
-			bal, _ := FctBalance(adr)
+			bal, nodeBal, err := addressBalance(ctx, "FA", adr, check)
 			sbal := primitives.ConvertDecimalToPaddedString(uint64(bal))
+			var note string
+			if err != nil {
+				note = "   check failed: " + err.Error()
+			} else if nodeBal != bal {
+				note = "   factomd: " + primitives.ConvertDecimalToString(uint64(nodeBal))
+			}
+			if Wallet.IsChange(we) {
+				changeAddresses = append(changeAddresses, adr)
+				changeKeys = append(changeKeys, name)
+				changeBalances = append(changeBalances, sbal)
+				changeNotes = append(changeNotes, note)
+				continue
+			}
+			fctAddresses = append(fctAddresses, adr)
+			fctKeys = append(fctKeys, name)
 			fctBalances = append(fctBalances, sbal)
+			fctNotes = append(fctNotes, note)
 		}
 	}
 	var out bytes.Buffer
 	if len(fctKeys) > 0 {
 		out.WriteString("\n  Factoid Addresses\n\n")
 	}
-	fstr := fmt.Sprintf("%s%vs    %s38s %s14s\n", "%", maxlen+4, "%", "%")
+	fstr := fmt.Sprintf("%s%vs    %s38s %s14s%ss\n", "%", maxlen+4, "%", "%", "%")
 	for i, key := range fctKeys {
-		str := fmt.Sprintf(fstr, key, fctAddresses[i], fctBalances[i])
+		str := fmt.Sprintf(fstr, key, fctAddresses[i], fctBalances[i], fctNotes[i])
+		out.WriteString(str)
+	}
+	if len(changeKeys) > 0 {
+		out.WriteString("\n  Change Addresses\n\n")
+	}
+	for i, key := range changeKeys {
+		str := fmt.Sprintf(fstr, key, changeAddresses[i], changeBalances[i], changeNotes[i])
 		out.WriteString(str)
 	}
 	if len(ecKeys) > 0 {
 		out.WriteString("\n  Entry Credit Addresses\n\n")
 	}
 	for i, key := range ecKeys {
-		str := fmt.Sprintf(fstr, key, ecAddresses[i], ecBalances[i])
+		str := fmt.Sprintf(fstr, key, ecAddresses[i], ecBalances[i], ecNotes[i])
 		out.WriteString(str)
 	}
 
@@ -686,7 +794,7 @@ func GetTransactionsj(ctx *web.Context) (string, error) {
 
 func HandleGetAddresses(ctx *web.Context) {
 	b := new(Response)
-	b.Response = string(GetAddresses())
+	b.Response = string(GetAddresses(ctx.Request.Context(), ctx.Params["check"] == "true"))
 	b.Success = true
 	j, err := json.Marshal(b)
 	if err != nil {
@@ -0,0 +1,115 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package scwallet
+
+import (
+	"fmt"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/database/bytestore"
+)
+
+// Transactions the wallet funds send what is left over to a change address.
+// That is the address set with SetChangeAddress, or if none is set, a fresh
+// address generated for the purpose.  Generated change addresses are
+// flagged on their wallet entry, so listings can set them apart.
+
+var walletSettingsBucket = []byte("wallet.settings")
+var changeAddressKey = []byte("change.address")
+
+// Prefix of the names of generated change addresses
+const ChangeAddressPrefix = "change-"
+
+func (w *WalletEntry) IsChange() bool {
+	return w.change
+}
+
+func (w *WalletEntry) SetChange(change bool) {
+	w.change = change
+}
+
+// SetChangeAddress makes the Factoid address with the given name take the
+// change of every transaction.  An empty name goes back to generating a
+// fresh change address each time.
+func (w *SCWallet) SetChangeAddress(name []byte) error {
+	if len(name) == 0 {
+		return w.db.Delete(walletSettingsBucket, changeAddressKey)
+	}
+	we, err := w.db.FetchWalletEntryByName(name)
+	if err != nil {
+		return err
+	}
+	if we == nil {
+		return fmt.Errorf("Unknown name '%s'", string(name))
+	}
+	if we.GetType() != "fct" {
+		return fmt.Errorf("'%s' is not a Factoid address", string(name))
+	}
+	b := new(bytestore.ByteStore)
+	b.SetBytes(name)
+	return w.db.Put(walletSettingsBucket, changeAddressKey, b)
+}
+
+// GetChangeAddress returns the name of the change address, or nil if a
+// fresh one is generated for each transaction.
+func (w *SCWallet) GetChangeAddress() ([]byte, error) {
+	v, err := w.db.Get(walletSettingsBucket, changeAddressKey, new(bytestore.ByteStore))
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, nil
+	}
+	return v.(*bytestore.ByteStore).Bytes(), nil
+}
+
+// ChangeAddress returns the address to send the change of a transaction
+// to, generating one if no change address is set.
+func (w *SCWallet) ChangeAddress() (interfaces.IAddress, error) {
+	name, err := w.GetChangeAddress()
+	if err != nil {
+		return nil, err
+	}
+	if name == nil {
+		return w.NewChangeAddress()
+	}
+	we, err := w.db.FetchWalletEntryByName(name)
+	if err != nil {
+		return nil, err
+	}
+	if we == nil {
+		return nil, fmt.Errorf("The change address '%s' is no longer in the wallet", string(name))
+	}
+	return we.GetAddress()
+}
+
+// NewChangeAddress generates a Factoid address flagged as a change address,
+// named with ChangeAddressPrefix and the first free number.
+func (w *SCWallet) NewChangeAddress() (interfaces.IAddress, error) {
+	w.writeMutex.Lock()
+	defer w.writeMutex.Unlock()
+	var name []byte
+	for i := 0; ; i++ {
+		name = []byte(fmt.Sprintf("%s%d", ChangeAddressPrefix, i))
+		we, err := w.db.FetchWalletEntryByName(name)
+		if err != nil {
+			return nil, err
+		}
+		if we == nil {
+			break
+		}
+	}
+
+	pub, pri, path, err := w.generateKey("fct")
+	if err != nil {
+		return nil, err
+	}
+	we, err := w.newKeyPairEntry("fct", name, pub, pri, path, true)
+	if err != nil {
+		return nil, err
+	}
+	we.SetChange(true)
+	return w.putWalletEntry(we)
+}
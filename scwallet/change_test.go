@@ -0,0 +1,58 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package scwallet
+
+import (
+	"testing"
+)
+
+func TestChangeAddress(t *testing.T) {
+	w, cleanup := newTestWallet(t)
+	defer cleanup()
+
+	a1, err := w.ChangeAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a2, err := w.ChangeAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a1.IsEqual(a2) == nil {
+		t.Error("Expected a fresh change address each time")
+	}
+	we, err := w.GetDB().FetchWalletEntryByName([]byte(ChangeAddressPrefix + "1"))
+	if err != nil || we == nil {
+		t.Fatalf("Missing the second change address: %v", err)
+	}
+	if !we.(*WalletEntry).IsChange() {
+		t.Error("Change address is not flagged as change")
+	}
+
+	if _, err := w.GenerateFctAddress([]byte("savings"), 1, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.SetChangeAddress([]byte("savings")); err != nil {
+		t.Fatal(err)
+	}
+	savings, err := w.GetDB().FetchWalletEntryByName([]byte("savings"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if savings.(*WalletEntry).IsChange() {
+		t.Error("Only generated change addresses are flagged")
+	}
+	a3, err := w.ChangeAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if adr, _ := savings.GetAddress(); a3.IsEqual(adr) != nil {
+		t.Error("Change should go to the configured address")
+	}
+
+	if err := w.SetChangeAddress([]byte("nobody")); err == nil {
+		t.Error("Set an unknown change address")
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package scwallet
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"fmt"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// Private keys and seeds are sealed with AES-256-GCM under a key derived
+// from the wallet passphrase with scrypt.  The salt and a sealed verifier
+// are kept in the database so a passphrase can be checked before it is used.
+
+var walletCryptoBucket = []byte("wallet.crypto")
+var walletCryptoKey = []byte("params")
+var walletVerifier = []byte("fctwallet passphrase verifier")
+
+const (
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+
+	cryptoKeyLength  = 32
+	cryptoSaltLength = 32
+)
+
+func deriveKey(passphrase []byte, salt []byte) ([]byte, error) {
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf("Passphrase cannot be empty")
+	}
+	return scrypt.Key(passphrase, salt, scryptN, scryptR, scryptP, cryptoKeyLength)
+}
+
+func newSalt() ([]byte, error) {
+	salt := make([]byte, cryptoSaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+// seal encrypts plaintext under key.  The additional data is authenticated
+// but not stored, so the same data must be presented to open.  The result
+// is the nonce followed by the ciphertext.
+func seal(key []byte, plaintext []byte, additional []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, additional), nil
+}
+
+func open(key []byte, sealed []byte, additional []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("Sealed data is too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, additional)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to decrypt. Wrong passphrase or corrupt wallet")
+	}
+	return plaintext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
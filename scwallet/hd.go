@@ -0,0 +1,116 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package scwallet
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// Addresses are derived from the root seed following SLIP-0010 for ed25519,
+// along BIP44 style paths:
+//
+//   m/44'/131'/0'/0'/i'   Factoid addresses
+//   m/44'/132'/0'/0'/i'   Entry Credit addresses
+//
+// ed25519 only supports hardened derivation, so every level is hardened.
+
+const (
+	HardenedOffset uint32 = 0x80000000
+
+	bip44Purpose        uint32 = 44
+	FactoidCoinType     uint32 = 131
+	EntryCreditCoinType uint32 = 132
+)
+
+var ed25519SeedKey = []byte("ed25519 seed")
+
+type extendedKey struct {
+	key       []byte
+	chainCode []byte
+}
+
+func newMasterKey(seed []byte) extendedKey {
+	mac := hmac.New(sha512.New, ed25519SeedKey)
+	mac.Write(seed)
+	i := mac.Sum(nil)
+	return extendedKey{key: i[:32], chainCode: i[32:]}
+}
+
+func (k extendedKey) child(index uint32) (extendedKey, error) {
+	if index < HardenedOffset {
+		return extendedKey{}, fmt.Errorf("ed25519 derivation requires hardened indexes")
+	}
+	data := make([]byte, 0, 37)
+	data = append(data, 0)
+	data = append(data, k.key...)
+	var ser [4]byte
+	binary.BigEndian.PutUint32(ser[:], index)
+	data = append(data, ser[:]...)
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data)
+	i := mac.Sum(nil)
+	return extendedKey{key: i[:32], chainCode: i[32:]}, nil
+}
+
+// derivePath returns the 32 byte ed25519 private key at the given path
+// under the seed.
+func derivePath(seed []byte, path []uint32) ([]byte, error) {
+	k := newMasterKey(seed)
+	for _, index := range path {
+		var err error
+		k, err = k.child(index)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return k.key, nil
+}
+
+func coinType(addrtype string) (uint32, error) {
+	switch addrtype {
+	case "fct":
+		return FactoidCoinType, nil
+	case "ec":
+		return EntryCreditCoinType, nil
+	}
+	return 0, fmt.Errorf("Invalid address type %s", addrtype)
+}
+
+// AddressPath returns the derivation path of the index'th address of the
+// given type.
+func AddressPath(addrtype string, index uint32) ([]uint32, error) {
+	coin, err := coinType(addrtype)
+	if err != nil {
+		return nil, err
+	}
+	if index >= HardenedOffset {
+		return nil, fmt.Errorf("Address index %d is out of range", index)
+	}
+	return []uint32{
+		bip44Purpose + HardenedOffset,
+		coin + HardenedOffset,
+		HardenedOffset,
+		HardenedOffset,
+		index + HardenedOffset,
+	}, nil
+}
+
+// PathString formats a derivation path as m/44'/131'/0'/0'/0'
+func PathString(path []uint32) string {
+	parts := []string{"m"}
+	for _, index := range path {
+		if index >= HardenedOffset {
+			parts = append(parts, fmt.Sprintf("%d'", index-HardenedOffset))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d", index))
+		}
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,83 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package scwallet
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+// Test vector 1 from SLIP-0010 for ed25519
+func TestDerivePathSLIP10(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	vectors := []struct {
+		path []uint32
+		key  string
+	}{
+		{[]uint32{}, "2b4be7f19ee27bbf30c667b642d5f4aa69fd169872f8fc3059c08ebae2eb19e7"},
+		{[]uint32{0 + HardenedOffset}, "68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3"},
+		{[]uint32{0 + HardenedOffset, 1 + HardenedOffset}, "b1d0bad404bf35da785a64ca1ac54b2617211d2777696fbffaf208f746ae84f2"},
+		{[]uint32{0 + HardenedOffset, 1 + HardenedOffset, 2 + HardenedOffset, 2 + HardenedOffset, 1000000000 + HardenedOffset},
+			"8f94d394a8e8fd6b1bc2f3f49f5c47e385281d5c17e65324b0f62483e37e8793"},
+	}
+	for _, v := range vectors {
+		key, err := derivePath(seed, v.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(key) != v.key {
+			t.Errorf("%s: got %x, expected %s", PathString(v.path), key, v.key)
+		}
+	}
+
+	if _, err := derivePath(seed, []uint32{1}); err == nil {
+		t.Error("Derived a non-hardened child")
+	}
+}
+
+func TestAddressPath(t *testing.T) {
+	path, err := AddressPath("fct", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if PathString(path) != "m/44'/131'/0'/0'/5'" {
+		t.Errorf("Unexpected path %s", PathString(path))
+	}
+	path, err = AddressPath("ec", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if PathString(path) != "m/44'/132'/0'/0'/0'" {
+		t.Errorf("Unexpected path %s", PathString(path))
+	}
+}
+
+func TestGeneratedAddressesAreRecoverable(t *testing.T) {
+	w, cleanup := newTestWallet(t)
+	defer cleanup()
+
+	for i := 0; i < 3; i++ {
+		name := []byte{byte('a' + i)}
+		if _, err := w.GenerateFctAddress(name, 1, 1); err != nil {
+			t.Fatal(err)
+		}
+		we, err := w.GetDB().FetchWalletEntryByName(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, _ := AddressPath("fct", uint32(i))
+		if PathString(we.(*WalletEntry).GetPath()) != PathString(expected) {
+			t.Errorf("Entry %d has path %s", i, PathString(we.(*WalletEntry).GetPath()))
+		}
+
+		pub, _, _, err := deriveKeyPair(w.RootSeed, "fct", uint32(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hex.EncodeToString(pub) != hex.EncodeToString(we.GetKey(0)) {
+			t.Errorf("Address %d does not match the key derived from the root seed", i)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package scwallet
+
+import (
+	"errors"
+	"time"
+
+	"github.com/FactomProject/factomd/common/constants"
+)
+
+// ErrWalletLocked is returned by anything that needs a private key while
+// an encrypted wallet is locked.
+var ErrWalletLocked = errors.New("Wallet locked")
+
+// afterFunc starts the relock timer.  Tests replace it to fire timers when
+// they choose.
+var afterFunc = time.AfterFunc
+
+// Unlock makes the private keys of an encrypted wallet usable for the given
+// duration, after which the wallet locks itself again.  Unlocking an already
+// unlocked wallet restarts the timeout.
+func (w *SCWallet) Unlock(passphrase []byte, timeout time.Duration) error {
+	if timeout <= 0 {
+		return errors.New("Unlock timeout must be positive")
+	}
+	key, err := w.checkPassphrase(passphrase)
+	if err != nil {
+		return err
+	}
+	// The root seed was forgotten with the key.  A wallet without one yet
+	// gets it when the first address is generated.
+	seed, err := w.getSeed([]byte(constants.W_SEEDS), constants.CURRENT_SEED[:], key)
+	if err != nil {
+		return err
+	}
+
+	w.lockMutex.Lock()
+	defer w.lockMutex.Unlock()
+
+	if w.relock != nil {
+		w.relock.Stop()
+	}
+	// A timer that fired before it was stopped may still be waiting on the
+	// mutex.  It only locks the wallet if nothing has happened since.
+	w.unlocks++
+	unlock := w.unlocks
+	w.key = key
+	w.setRootSeed(seed)
+	w.unlockedUntil = time.Now().Add(timeout)
+	w.relock = afterFunc(timeout, func() { w.relockAfter(unlock) })
+	return nil
+}
+
+// relockAfter locks the wallet when the timeout of the unlock runs out,
+// unless it has been unlocked or locked since.
+func (w *SCWallet) relockAfter(unlock uint64) {
+	w.lockMutex.Lock()
+	defer w.lockMutex.Unlock()
+	if w.unlocks == unlock {
+		w.lock()
+	}
+}
+
+// Lock forgets the wallet key and root seed.  Nothing can be signed until the wallet is
+// unlocked again.
+func (w *SCWallet) Lock() {
+	w.lockMutex.Lock()
+	defer w.lockMutex.Unlock()
+	w.unlocks++
+	w.lock()
+}
+
+// lock forgets the key and the root seed.  The lock mutex must be held.
+func (w *SCWallet) lock() {
+	if w.relock != nil {
+		w.relock.Stop()
+		w.relock = nil
+	}
+	for i := range w.key {
+		w.key[i] = 0
+	}
+	w.key = nil
+	w.setRootSeed(nil)
+	w.unlockedUntil = time.Time{}
+}
+
+// setRootSeed replaces the cached root seed, zeroing the old one.  The
+// lock mutex must be held.
+func (w *SCWallet) setRootSeed(seed []byte) {
+	for i := range w.RootSeed {
+		w.RootSeed[i] = 0
+	}
+	w.RootSeed = nil
+	if seed != nil {
+		w.RootSeed = append([]byte{}, seed...)
+	}
+}
+
+// cachedRootSeed returns a copy of the cached root seed, or nil if it
+// isn't loaded.  The copy is unaffected when the wallet locks.
+func (w *SCWallet) cachedRootSeed() []byte {
+	w.lockMutex.Lock()
+	defer w.lockMutex.Unlock()
+	if w.RootSeed == nil {
+		return nil
+	}
+	return append([]byte{}, w.RootSeed...)
+}
+
+// IsLocked is true if the wallet is encrypted and the key isn't available.
+// A wallet with no passphrase is never locked.
+func (w *SCWallet) IsLocked() (bool, error) {
+	_, err := w.walletKey()
+	if err == ErrWalletLocked {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return false, nil
+}
+
+// UnlockedUntil returns when the wallet will lock itself again, or the zero
+// time if it is locked.
+func (w *SCWallet) UnlockedUntil() time.Time {
+	w.lockMutex.Lock()
+	defer w.lockMutex.Unlock()
+	return w.unlockedUntil
+}
+
+func (w *SCWallet) currentKey() []byte {
+	w.lockMutex.Lock()
+	defer w.lockMutex.Unlock()
+	if w.key == nil {
+		return nil
+	}
+	return append([]byte{}, w.key...)
+}
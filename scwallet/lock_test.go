@@ -0,0 +1,238 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package scwallet
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func newTestWallet(t *testing.T) (*SCWallet, func()) {
+	dir, err := ioutil.TempDir("", "scwallet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := NewSCWallet(dir+"/", "test_wallet.db")
+	w.NewSeed([]byte("lkdfsgjlagkjlasd"))
+	return w, func() { os.RemoveAll(dir) }
+}
+
+func TestLockedWalletRefusesToSign(t *testing.T) {
+	w, cleanup := newTestWallet(t)
+	defer cleanup()
+
+	h1, err := w.GenerateFctAddress([]byte("test1"), 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2, err := w.GenerateFctAddress([]byte("test2"), 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.EncryptWallet([]byte("passphrase")); err != nil {
+		t.Fatal(err)
+	}
+
+	trans := w.CreateTransaction(0)
+	w.AddInput(trans, h1, 1000000)
+	w.AddOutput(trans, h2, 1000000-12000)
+
+	if _, err := w.SignInputs(trans); err != ErrWalletLocked {
+		t.Errorf("Expected %v, got %v", ErrWalletLocked, err)
+	}
+	if _, err := w.GenerateFctAddress([]byte("test3"), 1, 1); err != ErrWalletLocked {
+		t.Errorf("Expected %v, got %v", ErrWalletLocked, err)
+	}
+
+	if err := w.Unlock([]byte("wrong"), time.Minute); err == nil {
+		t.Error("Unlocked with the wrong passphrase")
+	}
+	if err := w.Unlock([]byte("passphrase"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	signed, err := w.SignInputs(trans)
+	if !signed || err != nil {
+		t.Errorf("Signing failed while unlocked: %v %v", signed, err)
+	}
+	if err := w.ValidateSignatures(trans); err != nil {
+		t.Error(err)
+	}
+
+	seed := w.RootSeed
+	w.Lock()
+	if locked, _ := w.IsLocked(); !locked {
+		t.Error("Wallet should be locked")
+	}
+	if w.RootSeed != nil || len(seed) == 0 || !bytes.Equal(seed, make([]byte, len(seed))) {
+		t.Error("The root seed was kept after locking")
+	}
+}
+
+// fakeTimers replaces the relock timer with one that only fires when the
+// test calls it, until the cleanup is called.
+func fakeTimers() (*[]func(), func()) {
+	var timers []func()
+	afterFunc = func(d time.Duration, f func()) *time.Timer {
+		timers = append(timers, f)
+		return time.AfterFunc(time.Hour, func() {})
+	}
+	return &timers, func() { afterFunc = time.AfterFunc }
+}
+
+func TestWalletRelocksAfterTimeout(t *testing.T) {
+	w, cleanup := newTestWallet(t)
+	defer cleanup()
+	timers, restore := fakeTimers()
+	defer restore()
+
+	if err := w.EncryptWallet([]byte("passphrase")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Unlock([]byte("passphrase"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if locked, _ := w.IsLocked(); locked {
+		t.Error("Wallet should be unlocked")
+	}
+	if w.RootSeed == nil {
+		t.Error("The root seed was not loaded on unlock")
+	}
+	(*timers)[0]()
+	if locked, _ := w.IsLocked(); !locked {
+		t.Error("Wallet should have locked itself")
+	}
+	if w.RootSeed != nil {
+		t.Error("The root seed was kept after the wallet locked itself")
+	}
+}
+
+func TestStaleRelockIsIgnored(t *testing.T) {
+	w, cleanup := newTestWallet(t)
+	defer cleanup()
+	timers, restore := fakeTimers()
+	defer restore()
+
+	if err := w.EncryptWallet([]byte("passphrase")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Unlock([]byte("passphrase"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	// The first timer fires as the wallet is unlocked again, too late to
+	// be stopped, and only gets the mutex after the second unlock.
+	if err := w.Unlock([]byte("passphrase"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	(*timers)[0]()
+	if locked, _ := w.IsLocked(); locked {
+		t.Error("A stale timer undid the unlock")
+	}
+	if w.UnlockedUntil().IsZero() {
+		t.Error("A stale timer cleared the unlock timeout")
+	}
+
+	(*timers)[1]()
+	if locked, _ := w.IsLocked(); !locked {
+		t.Error("Wallet should have locked itself")
+	}
+
+	// Nor does a timer after an explicit lock and unlock.
+	if err := w.Unlock([]byte("passphrase"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	w.Lock()
+	if err := w.Unlock([]byte("passphrase"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	(*timers)[2]()
+	if locked, _ := w.IsLocked(); locked {
+		t.Error("A stale timer undid the unlock")
+	}
+}
+
+func TestChangePassphrase(t *testing.T) {
+	w, cleanup := newTestWallet(t)
+	defer cleanup()
+
+	h1, err := w.GenerateFctAddress([]byte("test1"), 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.EncryptWallet([]byte("old")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.ChangePassphrase([]byte("wrong"), []byte("new")); err == nil {
+		t.Error("Changed the passphrase without the old one")
+	}
+	if err := w.ChangePassphrase([]byte("old"), []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Unlock([]byte("old"), time.Minute); err == nil {
+		t.Error("Old passphrase still unlocks the wallet")
+	}
+	if err := w.Unlock([]byte("new"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	trans := w.CreateTransaction(0)
+	w.AddInput(trans, h1, 1000000)
+	w.AddOutput(trans, h1, 1000000-12000)
+	signed, err := w.SignInputs(trans)
+	if !signed || err != nil {
+		t.Errorf("Signing failed under the new passphrase: %v %v", signed, err)
+	}
+
+	// Seeds moved too, so they can be reloaded to generate new addresses.
+	w.RootSeed = nil
+	if _, err := w.GenerateFctAddress([]byte("test2"), 1, 1); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestChangePassphraseWhileGenerating(t *testing.T) {
+	w, cleanup := newTestWallet(t)
+	defer cleanup()
+
+	if err := w.EncryptWallet([]byte("old")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Unlock([]byte("old"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	names := make(chan []byte, 20)
+	go func() {
+		defer close(names)
+		for i := 0; i < cap(names); i++ {
+			name := []byte(fmt.Sprintf("test%d", i))
+			if _, err := w.GenerateFctAddress(name, 1, 1); err != nil {
+				t.Error(err)
+				return
+			}
+			names <- name
+		}
+	}()
+	if err := w.ChangePassphrase([]byte("old"), []byte("new")); err != nil {
+		t.Fatal(err)
+	}
+
+	w.Lock()
+	if err := w.Unlock([]byte("new"), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	for name := range names {
+		we, err := w.db.FetchWalletEntryByName(name)
+		if err != nil || we == nil {
+			t.Fatalf("Missing %s: %v", name, err)
+		}
+		if _, err := w.GetPrivateKey(we, 0); err != nil {
+			t.Errorf("The key of %s does not open under the new passphrase: %v", name, err)
+		}
+	}
+}
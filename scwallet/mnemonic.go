@@ -0,0 +1,142 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package scwallet
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/go-bip39"
+)
+
+// The root seed of the wallet is the BIP39 seed of a mnemonic, with no
+// BIP39 passphrase.  The mnemonic is kept with the other seeds so it can be
+// shown again as a backup.
+
+const DefaultMnemonicWords = 24
+
+var mnemonicSeedKey = []byte("mnemonic")
+
+func mnemonicEntropyBits(words int) (int, error) {
+	switch words {
+	case 12, 15, 18, 21, 24:
+		return words * 32 / 3, nil
+	}
+	return 0, fmt.Errorf("A mnemonic must have 12, 15, 18, 21 or 24 words, not %d", words)
+}
+
+func normalizeMnemonic(mnemonic string) string {
+	return strings.Join(strings.Fields(strings.ToLower(mnemonic)), " ")
+}
+
+// NewMnemonicSeed gives a wallet with no addresses a fresh random root seed,
+// and returns the mnemonic to back it up with.
+func (w *SCWallet) NewMnemonicSeed(words int) (string, error) {
+	w.writeMutex.Lock()
+	defer w.writeMutex.Unlock()
+	key, err := w.walletKey()
+	if err != nil {
+		return "", err
+	}
+	if err := w.checkNoAddresses(); err != nil {
+		return "", err
+	}
+	return w.newMnemonicSeed(words, key)
+}
+
+func (w *SCWallet) newMnemonicSeed(words int, key []byte) (string, error) {
+	bits, err := mnemonicEntropyBits(words)
+	if err != nil {
+		return "", err
+	}
+	entropy, err := bip39.NewEntropy(bits)
+	if err != nil {
+		return "", err
+	}
+	mnemonic, err := bip39.NewMnemonic(entropy)
+	if err != nil {
+		return "", err
+	}
+	if err := w.setMnemonic(mnemonic, key); err != nil {
+		return "", err
+	}
+	return mnemonic, nil
+}
+
+// RestoreFromMnemonic sets the root seed of a wallet with no addresses from
+// a backup mnemonic.  Addresses generated afterwards are the same ones, in
+// the same order, as those generated by the wallet the mnemonic came from.
+func (w *SCWallet) RestoreFromMnemonic(mnemonic string) error {
+	w.writeMutex.Lock()
+	defer w.writeMutex.Unlock()
+	key, err := w.walletKey()
+	if err != nil {
+		return err
+	}
+	if err := w.checkNoAddresses(); err != nil {
+		return err
+	}
+	return w.setMnemonic(mnemonic, key)
+}
+
+// GetMnemonic returns the mnemonic of the wallet root seed.  Wallets seeded
+// from arbitrary data have no mnemonic.
+func (w *SCWallet) GetMnemonic() (string, error) {
+	w.writeMutex.Lock()
+	defer w.writeMutex.Unlock()
+	key, err := w.walletKey()
+	if err != nil {
+		return "", err
+	}
+	root, err := w.rootSeed(key)
+	if err != nil {
+		return "", err
+	}
+	data, err := w.getSeed([]byte(constants.W_SEEDS), mnemonicSeedKey, key)
+	if err != nil {
+		return "", err
+	}
+	if data == nil {
+		return "", fmt.Errorf("The wallet seed was not created from a mnemonic")
+	}
+	mnemonic := string(data)
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, "")
+	if err != nil {
+		return "", err
+	}
+	if !bytes.Equal(seed, root) {
+		return "", fmt.Errorf("The wallet seed was not created from a mnemonic")
+	}
+	return mnemonic, nil
+}
+
+func (w *SCWallet) setMnemonic(mnemonic string, key []byte) error {
+	mnemonic = normalizeMnemonic(mnemonic)
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, "")
+	if err != nil {
+		return fmt.Errorf("Invalid mnemonic: %v", err)
+	}
+	if err := w.storeSeed(seed, key); err != nil {
+		return err
+	}
+	if err := w.putSeed([]byte(constants.W_SEEDS), mnemonicSeedKey, []byte(mnemonic), key); err != nil {
+		return err
+	}
+	w.SetRoot(seed)
+	return nil
+}
+
+func (w *SCWallet) checkNoAddresses() error {
+	keys, err := w.db.FetchAllAddressNameKeys()
+	if err != nil {
+		return err
+	}
+	if len(keys) > 0 {
+		return fmt.Errorf("The wallet already has addresses. The seed can only be replaced in an empty wallet")
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package scwallet
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRestoreFromMnemonic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "scwallet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	w1 := NewSCWallet(dir+"/", "one.db")
+	mnemonic, err := w1.NewMnemonicSeed(12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(strings.Fields(mnemonic)) != 12 {
+		t.Fatalf("Expected 12 words, got %q", mnemonic)
+	}
+	exported, err := w1.GetMnemonic()
+	if err != nil || exported != mnemonic {
+		t.Fatalf("Exported %q, %v", exported, err)
+	}
+
+	w2 := NewSCWallet(dir+"/", "two.db")
+	if err := w2.RestoreFromMnemonic(strings.ToUpper(mnemonic)); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, name := range []string{"a", "b", "c"} {
+		a1, err := w1.GenerateFctAddress([]byte(name), 1, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		a2, err := w2.GenerateFctAddress([]byte(name), 1, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a1.IsEqual(a2) != nil {
+			t.Errorf("Factoid address %d differs after restore", i)
+		}
+		e1, _ := w1.GenerateECAddress([]byte("ec" + name))
+		e2, _ := w2.GenerateECAddress([]byte("ec" + name))
+		if e1 == nil || e2 == nil || e1.IsEqual(e2) != nil {
+			t.Errorf("Entry Credit address %d differs after restore", i)
+		}
+	}
+
+	if err := w2.RestoreFromMnemonic(mnemonic); err == nil {
+		t.Error("Replaced the seed of a wallet with addresses")
+	}
+	if err := NewSCWallet(dir+"/", "three.db").RestoreFromMnemonic("not a mnemonic"); err == nil {
+		t.Error("Accepted an invalid mnemonic")
+	}
+}
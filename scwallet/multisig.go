@@ -0,0 +1,167 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package scwallet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/FactomProject/factomd/common/constants"
+	. "github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/common/interfaces"
+)
+
+// Multisig addresses use an RCD_2.  The wallet entry holds the public key of
+// every participant in the order they appear in the RCD, and the private
+// keys of the participants this wallet holds.  Signature blocks for an RCD_2
+// carry one signature slot per participant, in the same order.  Slots this
+// wallet can't sign are left zero for the other participants.
+//
+// factomd doesn't check RCD_2 signatures yet and rejects every input that
+// has one, so funds sent to a multisig address can't be spent until it does.
+
+// Largest number of participants in a multisig address
+const MaxMultisigKeys = 16
+
+// ErrMultisigUnsupported is returned for a transaction with a multisig input.
+var ErrMultisigUnsupported = errors.New("factomd does not check multisig (RCD_2) signatures yet, so a transaction with a multisig input can't be submitted")
+
+// AddMultisigAddress creates an m of n address over the given public keys.
+// Any key that belongs to a single signature address in the wallet can be
+// signed with locally; the others are foreign keys signed elsewhere.
+func (w *SCWallet) AddMultisigAddress(name []byte, m int, publics [][]byte) (interfaces.IAddress, error) {
+	w.writeMutex.Lock()
+	defer w.writeMutex.Unlock()
+	privates := make([][]byte, len(publics))
+	for i, pub := range publics {
+		if len(pub) != constants.ADDRESS_LENGTH {
+			return nil, fmt.Errorf("Invalid public key %x", pub)
+		}
+		we, err := w.db.FetchWalletEntryByPublicKey(pub)
+		if err != nil {
+			return nil, err
+		}
+		if we == nil {
+			continue
+		}
+		pri, err := w.GetPrivateKey(we, 0)
+		if err == ErrWalletLocked {
+			return nil, err
+		}
+		if err == nil {
+			privates[i] = pri
+		}
+	}
+	return w.addMultisig(name, m, publics, privates)
+}
+
+func (w *SCWallet) addMultisig(name []byte, m int, publics [][]byte, privates [][]byte) (interfaces.IAddress, error) {
+	n := len(publics)
+	if n < 1 || n > MaxMultisigKeys {
+		return nil, fmt.Errorf("A multisig address must have between 1 and %d keys", MaxMultisigKeys)
+	}
+	if m < 1 || m > n {
+		return nil, fmt.Errorf("Cannot require %d of %d signatures", m, n)
+	}
+
+	nm, err := w.db.FetchWalletEntryByName(name)
+	if err != nil {
+		return nil, err
+	}
+	if nm != nil {
+		return nil, fmt.Errorf("The name '%s' already exists. Duplicate names are not supported", string(name))
+	}
+
+	key, err := w.walletKey()
+	if err != nil {
+		return nil, err
+	}
+
+	we := new(WalletEntry)
+	addresses := make([]interfaces.IAddress, n)
+	for i, pub := range publics {
+		adr, err := NewRCD_1(pub).GetAddress()
+		if err != nil {
+			return nil, err
+		}
+		addresses[i] = adr
+		if privates[i] != nil {
+			we.AddKey(pub, privates[i])
+		} else {
+			we.AddPublicKey(pub)
+		}
+	}
+	we.SetName(name)
+	we.SetType("fct")
+	we.SetRCD(&RCD_2{M: m, N: n, N_Addresses: addresses})
+
+	address, err := we.GetAddress()
+	if err != nil {
+		return nil, err
+	}
+	existing, err := w.db.Get([]byte(constants.W_RCD_ADDRESS_HASH), address.Bytes(), new(WalletEntry))
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("Address already exists in the wallet")
+	}
+
+	if err := w.saveWalletEntry(we, key); err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+// signMultisig fills in every signature slot of the RCD_2 that this wallet
+// has a key for, keeping signatures already collected.  It returns the new
+// signature block and the number of slots signed.
+func (w *SCWallet) signMultisig(rcd *RCD_2, data []byte, existing interfaces.ISignatureBlock) (interfaces.ISignatureBlock, int, error) {
+	address, err := rcd.GetAddress()
+	if err != nil {
+		return nil, 0, err
+	}
+	// If the address isn't ours, just keep the signatures already there.
+	v, err := w.db.Get([]byte(constants.W_RCD_ADDRESS_HASH), address.Bytes(), new(WalletEntry))
+	if err != nil {
+		return nil, 0, err
+	}
+	var we *WalletEntry
+	if v != nil {
+		we = v.(*WalletEntry)
+	}
+
+	block := new(SignatureBlock)
+	signed := 0
+	for j, sig := range signatureSlots(existing, rcd.N) {
+		if !HasSignature(sig) && we != nil {
+			pri, err := w.GetPrivateKey(we, j)
+			if err == nil {
+				sig = NewSingleSignatureBlock(pri, data).GetSignature(0)
+			} else if err != errNoPrivateKey && err != ErrWatchOnly {
+				return nil, 0, err
+			}
+		}
+		if HasSignature(sig) {
+			signed++
+		}
+		block.AddSignature(sig)
+	}
+	return block, signed, nil
+}
+
+// HasSignature is false for a missing signature, or an empty slot in a
+// multisig signature block.
+func HasSignature(sig interfaces.ISignature) bool {
+	if sig == nil || sig.GetSignature() == nil {
+		return false
+	}
+	for _, b := range sig.GetSignature() {
+		if b != 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package scwallet
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// sharedPublicKeys gives each wallet a Factoid address named "mine", and
+// returns their public keys.
+func sharedPublicKeys(t *testing.T, wallets ...*SCWallet) [][]byte {
+	var publics [][]byte
+	for _, w := range wallets {
+		if _, err := w.GenerateFctAddress([]byte("mine"), 1, 1); err != nil {
+			t.Fatal(err)
+		}
+		we, err := w.GetDB().FetchWalletEntryByName([]byte("mine"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		publics = append(publics, we.GetKey(0))
+	}
+	return publics
+}
+
+// checkMultisig verifies the signature of each participant in the first
+// input of the transaction.  factomd can't check RCD_2 signatures yet, so
+// ValidateSignatures always fails them.
+func checkMultisig(t *testing.T, trans interfaces.ITransaction, publics [][]byte) {
+	data, err := trans.MarshalBinarySig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sigs := trans.GetSignatureBlock(0).GetSignatures()
+	if len(sigs) != len(publics) {
+		t.Fatalf("%d signatures for %d keys", len(sigs), len(publics))
+	}
+	for j, sig := range sigs {
+		if !HasSignature(sig) || !primitives.VerifySlice(publics[j], data, sig.GetSignature()[:]) {
+			t.Errorf("Signature %d does not verify", j)
+		}
+	}
+}
+
+func TestMultisigSignedByTwoWallets(t *testing.T) {
+	w1, cleanup1 := newTestWallet(t)
+	defer cleanup1()
+	w2, cleanup2 := newTestWallet(t)
+	defer cleanup2()
+	w2.NewSeed([]byte("a different seed"))
+
+	publics := sharedPublicKeys(t, w1, w2)
+	a1, err := w1.AddMultisigAddress([]byte("shared"), 2, publics)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a2, err := w2.AddMultisigAddress([]byte("shared"), 2, publics)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a1.IsEqual(a2) != nil {
+		t.Fatal("Both wallets should build the same multisig address")
+	}
+	if _, err := w1.AddMultisigAddress([]byte("shared"), 3, publics); err == nil {
+		t.Error("Accepted 3 of 2 signatures")
+	}
+
+	trans := w1.CreateTransaction(0)
+	if err := w1.AddInput(trans, a1, 1000000); err != nil {
+		t.Fatal(err)
+	}
+	w1.AddOutput(trans, a1, 1000000-12000)
+
+	signed, err := w1.SignInputs(trans)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signed {
+		t.Error("One signature should not complete a 2 of 2 input")
+	}
+	signed, err = w2.SignInputs(trans)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !signed {
+		t.Error("The second wallet should complete the signatures")
+	}
+	checkMultisig(t, trans, publics)
+}
+
+func TestGenerateMultisigAddressRefused(t *testing.T) {
+	w, cleanup := newTestWallet(t)
+	defer cleanup()
+
+	// Keys generated together in one wallet would give a multisig address
+	// that wallet signs alone.
+	if _, err := w.GenerateFctAddress([]byte("two of three"), 2, 3); err == nil {
+		t.Error("Generated a multisig address with every key in one wallet")
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package scwallet
+
+import (
+	"bytes"
+	"fmt"
+
+	. "github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/common/interfaces"
+)
+
+// MergeSignatures copies into trans the signatures from other that trans is
+// missing.  Both must be the same transaction, inputs, outputs and RCDs,
+// but each may hold signatures the other doesn't have yet.
+func (w *SCWallet) MergeSignatures(trans interfaces.ITransaction, other interfaces.ITransaction) error {
+	data, err := trans.MarshalBinarySig()
+	if err != nil {
+		return err
+	}
+	odata, err := other.MarshalBinarySig()
+	if err != nil {
+		return err
+	}
+	rcds := trans.GetRCDs()
+	orcds := other.GetRCDs()
+	if !bytes.Equal(data, odata) || len(rcds) != len(orcds) {
+		return fmt.Errorf("Cannot merge signatures from a different transaction")
+	}
+
+	for i, rcd := range rcds {
+		r1, err := rcd.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		r2, err := orcds[i].MarshalBinary()
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(r1, r2) {
+			return fmt.Errorf("Cannot merge signatures from a different transaction")
+		}
+
+		n := 1
+		if rcd2, ok := rcd.(*RCD_2); ok {
+			n = rcd2.N
+		}
+		theirs := signatureSlots(other.GetSignatureBlock(i), n)
+		block := new(SignatureBlock)
+		for j, sig := range signatureSlots(trans.GetSignatureBlock(i), n) {
+			if !HasSignature(sig) {
+				sig = theirs[j]
+			} else if HasSignature(theirs[j]) && !bytes.Equal(sig.GetSignature()[:], theirs[j].GetSignature()[:]) {
+				return fmt.Errorf("Conflicting signatures for input %d", i)
+			}
+			block.AddSignature(sig)
+		}
+		trans.SetSignatureBlock(i, block)
+	}
+	return nil
+}
+
+// signatureSlots returns the n signatures of a signature block, with empty
+// signatures for any the block doesn't have.
+func signatureSlots(block interfaces.ISignatureBlock, n int) []interfaces.ISignature {
+	sigs := make([]interfaces.ISignature, n)
+	for j := range sigs {
+		if block != nil && j < len(block.GetSignatures()) && block.GetSignature(j) != nil {
+			sigs[j] = block.GetSignature(j)
+		} else {
+			sigs[j] = new(FactoidSignature)
+		}
+	}
+	return sigs
+}
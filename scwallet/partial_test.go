@@ -0,0 +1,59 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package scwallet
+
+import (
+	"testing"
+
+	. "github.com/FactomProject/factomd/common/factoid"
+)
+
+func TestMergeSignatures(t *testing.T) {
+	w1, cleanup1 := newTestWallet(t)
+	defer cleanup1()
+	w2, cleanup2 := newTestWallet(t)
+	defer cleanup2()
+	w2.NewSeed([]byte("a different seed"))
+
+	publics := sharedPublicKeys(t, w1, w2)
+	adr, err := w1.AddMultisigAddress([]byte("shared"), 2, publics)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w2.AddMultisigAddress([]byte("shared"), 2, publics); err != nil {
+		t.Fatal(err)
+	}
+
+	trans := w1.CreateTransaction(0)
+	w1.AddInput(trans, adr, 1000000)
+	w1.AddOutput(trans, adr, 1000000-12000)
+	if signed, err := w1.SignInputs(trans); signed || err != nil {
+		t.Fatalf("Expected a partial signature, got %v %v", signed, err)
+	}
+
+	// Ship the partially signed transaction to the other wallet and sign
+	data, err := trans.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	received := new(Transaction)
+	if err := received.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if signed, err := w2.SignInputs(received); !signed || err != nil {
+		t.Fatalf("Expected all signatures, got %v %v", signed, err)
+	}
+
+	if err := w1.MergeSignatures(trans, received); err != nil {
+		t.Fatal(err)
+	}
+	checkMultisig(t, trans, publics)
+
+	other := w1.CreateTransaction(1)
+	w1.AddInput(other, adr, 2000000)
+	if err := w1.MergeSignatures(trans, other); err == nil {
+		t.Error("Merged signatures from a different transaction")
+	}
+}
@@ -10,11 +10,14 @@
 package scwallet
 
 import (
-	"crypto/rand"
+	"bytes"
 	"crypto/sha512"
+	"encoding/binary"
 	"fmt"
 	"github.com/FactomProject/ed25519"
 	"os"
+	"sync"
+	"time"
 
 	"github.com/FactomProject/factomd/common/constants"
 	. "github.com/FactomProject/factomd/common/factoid"
@@ -24,16 +27,40 @@ import (
 	"github.com/FactomProject/factomd/database/hybridDB"
 )
 
-var factoshisPerEC uint64 = 100000
+// Factoshis per entry credit until factomd has been asked
+const factoshisPerEC uint64 = 100000
 
 type SCWallet struct {
 	db            interfaces.ISCDatabaseOverlay
 	isInitialized bool //defaults to 0 and false
 	RootSeed      []byte
-	NextSeed      []byte
+	NextSeed      []byte // Deprecated: addresses are derived from RootSeed by HD path; nothing reads or sets it
+	key           []byte // Key private keys are sealed under, while unlocked
+	unlockedUntil time.Time
+	relock        *time.Timer
+	unlocks       uint64 // Counts unlocks and locks, so a stale relock does nothing
+	lockMutex     sync.Mutex
+	writeMutex    sync.Mutex // Held while keys and seeds are written, so a passphrase change doesn't miss one
+	ecRate        uint64     // Factoshis per entry credit last seen, 0 until then
+	ecRateMutex   sync.Mutex
 }
 
-var _ interfaces.ISCWallet = (*SCWallet)(nil)
+// A FactomdWallet is an SCWallet as factomd's interfaces.ISCWallet, whose
+// SignCommit has no way to report an error.  A commit that can't be
+// signed, such as while the wallet is locked, is signed as nil.
+type FactomdWallet struct {
+	*SCWallet
+}
+
+var _ interfaces.ISCWallet = FactomdWallet{}
+
+func (w FactomdWallet) SignCommit(we interfaces.IWalletEntry, data []byte) []byte {
+	sig, err := w.SCWallet.SignCommit(we, data)
+	if err != nil {
+		return nil
+	}
+	return sig
+}
 
 func NewSCWallet(path, filename string) *SCWallet {
 	w := new(SCWallet)
@@ -60,7 +87,9 @@ func (SCWallet) GetHash() interfaces.IHash {
  ***************************************/
 
 func (w *SCWallet) SetRoot(root []byte) {
-	w.RootSeed = root
+	w.lockMutex.Lock()
+	defer w.lockMutex.Unlock()
+	w.setRootSeed(root)
 }
 
 func (w *SCWallet) GetDB() interfaces.ISCDatabaseOverlay {
@@ -78,40 +107,366 @@ func (w *SCWallet) SignInputs(trans interfaces.ITransaction) (bool, error) {
 	inputs := trans.GetInputs()
 	rcds := trans.GetRCDs()
 	for i, rcd := range rcds {
-		rcd1, ok := rcd.(*RCD_1)
-		if ok {
-			pub := rcd1.GetPublicKey()
+		switch r := rcd.(type) {
+		case *RCD_1:
+			pub := r.GetPublicKey()
 			we, err := w.db.FetchWalletEntryByPublicKey(pub)
 			if err != nil {
 				return false, err
 			}
-			if we != nil {
-				sig := NewSingleSignatureBlock(we.GetPrivKey(0), data)
+			// Watch-only inputs are left for whoever holds the key
+			if we != nil && !we.(*WalletEntry).IsWatchOnly() {
+				pri, err := w.GetPrivateKey(we, 0)
+				if err != nil {
+					return false, err
+				}
+				sig := NewSingleSignatureBlock(pri, data)
 				trans.SetSignatureBlock(i, sig)
 				numSigs += 1
 			}
+		case *RCD_2:
+			// Add whatever signatures we can; other participants may add
+			// the rest.
+			sig, signed, err := w.signMultisig(r, data, trans.GetSignatureBlock(i))
+			if err != nil {
+				return false, err
+			}
+			trans.SetSignatureBlock(i, sig)
+			if signed >= r.M {
+				numSigs += 1
+			}
 		}
 	}
 
 	return numSigs == len(inputs), nil
 }
 
+// SignInputsWithKey signs the inputs of the transaction that belong to the
+// given private key, which need not be in the wallet.  Returns true if that
+// signs every input.
+func (w *SCWallet) SignInputsWithKey(trans interfaces.ITransaction, privateKey []byte) (bool, error) {
+	pub, pri, err := w.generateKeyFromPrivateKey(privateKey)
+	if err != nil {
+		return false, err
+	}
+	data, err := trans.MarshalBinarySig()
+	if err != nil {
+		return false, err
+	}
+
+	var numSigs int = 0
+	for i, rcd := range trans.GetRCDs() {
+		rcd1, ok := rcd.(*RCD_1)
+		if ok && bytes.Equal(rcd1.GetPublicKey(), pub) {
+			trans.SetSignatureBlock(i, NewSingleSignatureBlock(pri, data))
+			numSigs += 1
+		}
+	}
+	return numSigs == len(trans.GetInputs()), nil
+}
+
 // SignCommit will sign the []byte with the Entry Credit Key and return the
 // slice with the signature and pubkey appended.
-func (w *SCWallet) SignCommit(we interfaces.IWalletEntry, data []byte) []byte {
+func (w *SCWallet) SignCommit(we interfaces.IWalletEntry, data []byte) ([]byte, error) {
+	key, err := w.GetPrivateKey(we, 0)
+	if err != nil {
+		return nil, err
+	}
 	pub := new([constants.ADDRESS_LENGTH]byte)
 	copy(pub[:], we.GetKey(0))
 	pri := new([constants.PRIVATE_LENGTH]byte)
-	copy(pri[:], we.GetPrivKey(0))
+	copy(pri[:], key)
 	sig := ed25519.Sign(pri, data)
 	r := append(data, pub[:]...)
 	r = append(r, sig[:]...)
 
-	return r
+	return r, nil
+}
+
+var errNoPrivateKey = fmt.Errorf("No private key for this address")
+
+// GetPrivateKey returns the ith private key of the wallet entry, decrypting
+// it if the wallet is encrypted.
+func (w *SCWallet) GetPrivateKey(we interfaces.IWalletEntry, i int) ([]byte, error) {
+	e, ok := we.(*WalletEntry)
+	if ok && e.IsWatchOnly() {
+		return nil, ErrWatchOnly
+	}
+	key, err := w.walletKey()
+	if err != nil {
+		return nil, err
+	}
+	if ok && e.IsSealed() {
+		if key == nil {
+			return nil, fmt.Errorf("Private key is encrypted but the wallet has no passphrase")
+		}
+		if err := e.Unseal(key); err != nil {
+			return nil, err
+		}
+	}
+	if ok && (i >= len(e.private) || len(e.private[i]) == 0) {
+		return nil, errNoPrivateKey
+	}
+	return we.GetPrivKey(i), nil
+}
+
+/***************************************
+ *       Encryption
+ ***************************************/
+
+// Returns the salt and sealed verifier for the wallet passphrase, or nils
+// if the wallet has never been encrypted.
+func (w *SCWallet) cryptoParams() (salt []byte, verifier []byte, err error) {
+	v, err := w.db.Get(walletCryptoBucket, walletCryptoKey, new(bytestore.ByteStore))
+	if err != nil {
+		return nil, nil, err
+	}
+	if v == nil {
+		return nil, nil, nil
+	}
+	data := v.(*bytestore.ByteStore).Bytes()
+	if len(data) <= cryptoSaltLength {
+		return nil, nil, fmt.Errorf("Wallet encryption parameters are corrupt")
+	}
+	return data[:cryptoSaltLength], data[cryptoSaltLength:], nil
+}
+
+func (w *SCWallet) IsEncrypted() (bool, error) {
+	salt, _, err := w.cryptoParams()
+	if err != nil {
+		return false, err
+	}
+	return salt != nil, nil
+}
+
+// walletKey returns the key private keys are sealed under, or nil if the
+// wallet is not encrypted.  It fails with ErrWalletLocked while an encrypted
+// wallet is locked.
+func (w *SCWallet) walletKey() ([]byte, error) {
+	encrypted, err := w.IsEncrypted()
+	if err != nil {
+		return nil, err
+	}
+	if !encrypted {
+		return nil, nil
+	}
+	key := w.currentKey()
+	if key == nil {
+		return nil, ErrWalletLocked
+	}
+	return key, nil
+}
+
+// checkPassphrase derives the wallet key from the passphrase and makes sure
+// it opens the stored verifier.
+func (w *SCWallet) checkPassphrase(passphrase []byte) ([]byte, error) {
+	salt, verifier, err := w.cryptoParams()
+	if err != nil {
+		return nil, err
+	}
+	if salt == nil {
+		return nil, fmt.Errorf("The wallet is not encrypted")
+	}
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := open(key, verifier, nil); err != nil {
+		return nil, fmt.Errorf("Incorrect passphrase")
+	}
+	return key, nil
+}
+
+func cryptoParamsRecord(salt []byte, key []byte) (interfaces.Record, error) {
+	verifier, err := seal(key, walletVerifier, nil)
+	if err != nil {
+		return interfaces.Record{}, err
+	}
+	b := new(bytestore.ByteStore)
+	b.SetBytes(append(append([]byte{}, salt...), verifier...))
+	return interfaces.Record{Bucket: walletCryptoBucket, Key: walletCryptoKey, Data: b}, nil
+}
+
+// EncryptWallet encrypts the private keys and seeds of a wallet that has no
+// passphrase yet.  From then on, private keys are only written to the
+// database encrypted.  The wallet is left locked.
+func (w *SCWallet) EncryptWallet(passphrase []byte) error {
+	w.writeMutex.Lock()
+	defer w.writeMutex.Unlock()
+	encrypted, err := w.IsEncrypted()
+	if err != nil {
+		return err
+	}
+	if encrypted {
+		return fmt.Errorf("The wallet is already encrypted")
+	}
+
+	salt, err := newSalt()
+	if err != nil {
+		return err
+	}
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return err
+	}
+
+	if err := w.reseal(nil, salt, key); err != nil {
+		return err
+	}
+
+	w.Lock()
+	return nil
+}
+
+// ChangePassphrase moves every private key and seed in the wallet from the
+// old passphrase to the new one.  If the wallet is unlocked it stays
+// unlocked under the new passphrase.
+func (w *SCWallet) ChangePassphrase(oldPassphrase []byte, newPassphrase []byte) error {
+	w.writeMutex.Lock()
+	defer w.writeMutex.Unlock()
+	oldKey, err := w.checkPassphrase(oldPassphrase)
+	if err != nil {
+		return err
+	}
+	salt, err := newSalt()
+	if err != nil {
+		return err
+	}
+	newKey, err := deriveKey(newPassphrase, salt)
+	if err != nil {
+		return err
+	}
+
+	if err := w.reseal(oldKey, salt, newKey); err != nil {
+		return err
+	}
+
+	w.lockMutex.Lock()
+	defer w.lockMutex.Unlock()
+	if w.key != nil {
+		w.key = newKey
+	}
+	return nil
+}
+
+// reseal re-encrypts every wallet entry and seed under newKey, and records
+// the new passphrase parameters.  A nil oldKey means they are currently in
+// the clear.  Everything is written in one database transaction, so the
+// wallet is never left half under one key and half under the other.  The
+// write mutex must be held, so no key is written under oldKey meanwhile.
+func (w *SCWallet) reseal(oldKey []byte, salt []byte, newKey []byte) error {
+	var records []interfaces.Record
+
+	entries, err := w.db.FetchAllWalletEntriesByName()
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		we := e.(*WalletEntry)
+		if we.IsSealed() {
+			if oldKey == nil {
+				return fmt.Errorf("Found an encrypted key in a wallet with no passphrase")
+			}
+			if err := we.Unseal(oldKey); err != nil {
+				return err
+			}
+		}
+		if we.hasPrivateKeys() {
+			if err := we.Seal(newKey); err != nil {
+				return err
+			}
+		}
+		r, err := walletEntryRecords(we)
+		if err != nil {
+			return err
+		}
+		records = append(records, r...)
+	}
+
+	for _, bucket := range [][]byte{[]byte(constants.W_SEEDS), []byte(constants.W_SEED_HEADS)} {
+		keys, err := w.db.ListAllKeys(bucket)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			seed, err := w.getSeed(bucket, key, oldKey)
+			if err != nil {
+				return err
+			}
+			r, err := seedRecord(bucket, key, seed, newKey)
+			if err != nil {
+				return err
+			}
+			records = append(records, r)
+		}
+	}
+
+	params, err := cryptoParamsRecord(salt, newKey)
+	if err != nil {
+		return err
+	}
+	records = append(records, params)
+
+	return w.db.PutInBatch(records)
+}
+
+// walletEntryRecords returns the records an entry is stored under: its
+// address, name, and public key if it has just the one.
+func walletEntryRecords(we *WalletEntry) ([]interfaces.Record, error) {
+	address, err := we.GetAddress()
+	if err != nil {
+		return nil, err
+	}
+	records := []interfaces.Record{
+		{Bucket: []byte(constants.W_RCD_ADDRESS_HASH), Key: address.Bytes(), Data: we},
+		{Bucket: []byte(constants.W_NAME), Key: we.GetName(), Data: we},
+	}
+	if we.IsSingleKey() {
+		records = append(records, interfaces.Record{Bucket: []byte(constants.W_ADDRESS_PUB_KEY), Key: we.GetKey(0), Data: we})
+	}
+	return records, nil
+}
+
+// saveWalletEntry writes the entry under its address, public key and name,
+// sealing the private keys first if a key is given.
+func (w *SCWallet) saveWalletEntry(we *WalletEntry, key []byte) error {
+	if key != nil && we.hasPrivateKeys() {
+		if err := we.Seal(key); err != nil {
+			return err
+		}
+	}
+	address, err := we.GetAddress()
+	if err != nil {
+		return err
+	}
+	err = w.db.SaveRCDAddress(address.Bytes(), we)
+	if err != nil {
+		return err
+	}
+	// Multisig keys are found through their own single signature entries
+	if we.IsSingleKey() {
+		err = w.db.SaveAddressByPublicKey(we.GetKey(0), we)
+		if err != nil {
+			return err
+		}
+	}
+	return w.db.SaveAddressByName(we.GetName(), we)
 }
 
 func (w *SCWallet) GetECRate() uint64 {
-	return factoshisPerEC
+	w.ecRateMutex.Lock()
+	defer w.ecRateMutex.Unlock()
+	if w.ecRate == 0 {
+		return factoshisPerEC
+	}
+	return w.ecRate
+}
+
+// SetECRate records the factoshis per entry credit last seen from factomd.
+func (w *SCWallet) SetECRate(rate uint64) {
+	w.ecRateMutex.Lock()
+	defer w.ecRateMutex.Unlock()
+	w.ecRate = rate
 }
 
 func (w *SCWallet) GetAddressDetailsAddr(name []byte) (interfaces.IWalletEntry, error) {
@@ -120,8 +475,10 @@ func (w *SCWallet) GetAddressDetailsAddr(name []byte) (interfaces.IWalletEntry,
 }
 
 func (w *SCWallet) generateAddressFromPrivateKey(addrtype string, name []byte, privateKey []byte, m int, n int) (interfaces.IAddress, error) {
+	w.writeMutex.Lock()
+	defer w.writeMutex.Unlock()
 	if addrtype == "fct" && (m != 1 || n != 1) {
-		return nil, fmt.Errorf("Multisig addresses are not supported at this time")
+		return nil, fmt.Errorf("A single private key only makes a 1 of 1 address. Use AddMultisigAddress to combine keys")
 	}
 
 	// Get a new public/private key pair
@@ -130,24 +487,59 @@ func (w *SCWallet) generateAddressFromPrivateKey(addrtype string, name []byte, p
 		return nil, err
 	}
 
-	return w.AddKeyPair(addrtype, name, pub, pri, false)
+	return w.addKeyPair(addrtype, name, pub, pri, nil, false)
 }
 
 func (w *SCWallet) generateAddress(addrtype string, name []byte, m int, n int) (interfaces.IAddress, error) {
+	w.writeMutex.Lock()
+	defer w.writeMutex.Unlock()
 	if addrtype == "fct" && (m != 1 || n != 1) {
-		return nil, fmt.Errorf("Multisig addresses are not supported at this time")
+		return nil, fmt.Errorf("A generated key only makes a 1 of 1 address. Use AddMultisigAddress to combine the keys of the participants")
 	}
 
 	// Get a new public/private key pair
-	pub, pri, err := w.generateKey()
+	pub, pri, path, err := w.generateKey(addrtype)
 	if err != nil {
 		return nil, err
 	}
 
-	return w.AddKeyPair(addrtype, name, pub, pri, true)
+	return w.addKeyPair(addrtype, name, pub, pri, path, true)
 }
 
 func (w *SCWallet) AddKeyPair(addrtype string, name []byte, pub []byte, pri []byte, generateRandomIfAddressPresent bool) (address interfaces.IAddress, err error) {
+	w.writeMutex.Lock()
+	defer w.writeMutex.Unlock()
+	return w.addKeyPair(addrtype, name, pub, pri, nil, generateRandomIfAddressPresent)
+}
+
+// addKeyPair adds the key pair to the wallet under the given name.  The path
+// is where the pair was derived from the root seed, or nil for imported keys.
+func (w *SCWallet) addKeyPair(addrtype string, name []byte, pub []byte, pri []byte, path []uint32, generateRandomIfAddressPresent bool) (address interfaces.IAddress, err error) {
+	we, err := w.newKeyPairEntry(addrtype, name, pub, pri, path, generateRandomIfAddressPresent)
+	if err != nil {
+		return nil, err
+	}
+	return w.putWalletEntry(we)
+}
+
+// putWalletEntry saves a new entry, encrypting its keys if the wallet is
+// encrypted, and returns its address.
+func (w *SCWallet) putWalletEntry(we *WalletEntry) (interfaces.IAddress, error) {
+	key, err := w.walletKey()
+	if err != nil {
+		return nil, err
+	}
+	address, _ := we.GetAddress()
+	err = w.saveWalletEntry(we, key)
+	if err != nil {
+		return nil, err
+	}
+	return address, nil
+}
+
+// newKeyPairEntry builds the wallet entry for a key pair, making sure
+// neither the name nor the key is in the wallet already.
+func (w *SCWallet) newKeyPairEntry(addrtype string, name []byte, pub []byte, pri []byte, path []uint32, generateRandomIfAddressPresent bool) (*WalletEntry, error) {
 	we := new(WalletEntry)
 
 	nm, err := w.db.FetchWalletEntryByName(name)
@@ -167,7 +559,7 @@ func (w *SCWallet) AddKeyPair(addrtype string, name []byte, pub []byte, pri []by
 			break
 		}
 		if generateRandomIfAddressPresent {
-			pub, pri, err = w.generateKey()
+			pub, pri, path, err = w.generateKey(addrtype)
 			if err != nil {
 				return nil, err
 			}
@@ -178,28 +570,42 @@ func (w *SCWallet) AddKeyPair(addrtype string, name []byte, pub []byte, pri []by
 
 	we.AddKey(pub, pri)
 	we.SetName(name)
+	we.SetPath(path)
 	we.SetRCD(NewRCD_1(pub))
 	if addrtype == "fct" {
 		we.SetType("fct")
 	} else {
 		we.SetType("ec")
 	}
-	//
-	address, _ = we.GetAddress()
-	err = w.db.SaveRCDAddress(address.Bytes(), we)
+	return we, nil
+}
+
+// AddDerivedAddress adds the address at the given derivation index to the
+// wallet under name.  Generated addresses continue after it.
+func (w *SCWallet) AddDerivedAddress(addrtype string, name []byte, index uint32) (interfaces.IAddress, error) {
+	w.writeMutex.Lock()
+	defer w.writeMutex.Unlock()
+
+	key, err := w.walletKey()
 	if err != nil {
 		return nil, err
 	}
-	err = w.db.SaveAddressByPublicKey(pub, we)
+	root, err := w.rootSeed(key)
 	if err != nil {
 		return nil, err
 	}
-	err = w.db.SaveAddressByName(name, we)
+	pub, pri, path, err := deriveKeyPair(root, addrtype, index)
 	if err != nil {
 		return nil, err
 	}
-
-	return
+	address, err := w.addKeyPair(addrtype, name, pub, pri, path, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.skipIndex(root, addrtype, index, key); err != nil {
+		return nil, err
+	}
+	return address, nil
 }
 
 func (w *SCWallet) GenerateECAddress(name []byte) (hash interfaces.IAddress, err error) {
@@ -247,84 +653,228 @@ func (w *SCWallet) NewSeed(data []byte) {
 	hasher := sha512.New()
 	hasher.Write(data)
 	seedhash := hasher.Sum(nil)
-	w.NextSeed = seedhash
-	w.RootSeed = seedhash
-	b := new(bytestore.ByteStore)
-	b.SetBytes(w.RootSeed)
-	err := w.db.Put([]byte(constants.W_SEEDS), constants.CURRENT_SEED[:], b)
+	w.SetSeed(seedhash)
+}
+
+func (w *SCWallet) SetSeed(seed []byte) {
+	w.writeMutex.Lock()
+	defer w.writeMutex.Unlock()
+	key, err := w.walletKey()
 	if err != nil {
 		panic(err)
 	}
-	err = w.db.Put([]byte(constants.W_SEEDS), w.RootSeed[:32], b)
+	err = w.storeSeed(seed, key)
 	if err != nil {
 		panic(err)
 	}
-	err = w.db.Put([]byte(constants.W_SEED_HEADS), w.RootSeed[:32], b)
+	w.SetRoot(seed)
+}
+
+// GetSeed returns the root seed of the wallet, creating a random one if the
+// wallet doesn't have one yet.
+func (w *SCWallet) GetSeed() []byte {
+	w.writeMutex.Lock()
+	defer w.writeMutex.Unlock()
+	key, err := w.walletKey()
+	if err != nil {
+		panic(err)
+	}
+	seed, err := w.rootSeed(key)
 	if err != nil {
 		panic(err)
 	}
+	return seed
 }
 
-func (w *SCWallet) SetSeed(seed []byte) {
-	w.NextSeed = seed
-	w.RootSeed = seed
+// rootSeed returns a copy of the root seed, loading it if the wallet
+// forgot it when it locked.
+func (w *SCWallet) rootSeed(key []byte) ([]byte, error) {
+	if root := w.cachedRootSeed(); root != nil {
+		return root, nil
+	}
+	root, err := w.getSeed([]byte(constants.W_SEEDS), constants.CURRENT_SEED[:], key)
+	if err != nil {
+		return nil, err
+	}
+	if root == nil {
+		// New wallets get a seed that can be backed up as a mnemonic
+		_, err := w.newMnemonicSeed(DefaultMnemonicWords, key)
+		if err != nil {
+			return nil, err
+		}
+		return w.cachedRootSeed(), nil
+	}
+	w.SetRoot(root)
+	return root, nil
+}
+
+// storeSeed records the root seed as the current seed, and starts address
+// derivation from it at index zero.
+func (w *SCWallet) storeSeed(root []byte, key []byte) error {
+	err := w.putSeed([]byte(constants.W_SEEDS), constants.CURRENT_SEED[:], root, key)
+	if err != nil {
+		return err
+	}
+	err = w.putSeed([]byte(constants.W_SEEDS), root[:32], root, key)
+	if err != nil {
+		return err
+	}
+	return w.putSeed([]byte(constants.W_SEED_HEADS), root[:32], make([]byte, 8), key)
+}
+
+// Seeds are bound to the bucket and key they are stored under when sealed.
+func seedData(bucket []byte, key []byte) []byte {
+	return append(append([]byte{}, bucket...), key...)
+}
+
+func seedRecord(bucket []byte, key []byte, seed []byte, wkey []byte) (interfaces.Record, error) {
+	data := seed
+	if wkey != nil {
+		var err error
+		data, err = seal(wkey, seed, seedData(bucket, key))
+		if err != nil {
+			return interfaces.Record{}, err
+		}
+	}
 	b := new(bytestore.ByteStore)
-	b.SetBytes(w.RootSeed)
-	err := w.db.Put([]byte(constants.W_SEEDS), constants.CURRENT_SEED[:], b)
+	b.SetBytes(data)
+	return interfaces.Record{Bucket: bucket, Key: key, Data: b}, nil
+}
+
+func (w *SCWallet) putSeed(bucket []byte, key []byte, seed []byte, wkey []byte) error {
+	r, err := seedRecord(bucket, key, seed, wkey)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	err = w.db.Put([]byte(constants.W_SEEDS), w.RootSeed[:32], b)
+	return w.db.Put(r.Bucket, r.Key, r.Data)
+}
+
+func (w *SCWallet) getSeed(bucket []byte, key []byte, wkey []byte) ([]byte, error) {
+	v, err := w.db.Get(bucket, key, new(bytestore.ByteStore))
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+	if v == nil {
+		return nil, nil
+	}
+	data := v.(*bytestore.ByteStore).Bytes()
+	if wkey != nil {
+		return open(wkey, data, seedData(bucket, key))
 	}
-	err = w.db.Put([]byte(constants.W_SEED_HEADS), w.RootSeed[:32], b)
+	return data, nil
+}
+
+// nextIndex returns the next unused derivation index for the address type
+// and moves the counter past it.  The counters are kept in W_SEED_HEADS as
+// the Factoid index followed by the Entry Credit index.  They only save
+// work; any address can be recovered by deriving from index zero.
+func (w *SCWallet) nextIndex(root []byte, addrtype string, key []byte) (uint32, error) {
+	counters, err := w.getCounters(root, key)
 	if err != nil {
-		panic(err)
+		return 0, err
 	}
+	offset := counterOffset(addrtype)
+	index := binary.BigEndian.Uint32(counters[offset:])
+	binary.BigEndian.PutUint32(counters[offset:], index+1)
+
+	err = w.putSeed([]byte(constants.W_SEED_HEADS), root[:32], counters, key)
+	if err != nil {
+		return 0, err
+	}
+	return index, nil
 }
 
-func (w *SCWallet) GetSeed() []byte {
-	iroot, err := w.db.Get([]byte(constants.W_SEEDS), constants.CURRENT_SEED[:], new(bytestore.ByteStore))
+// skipIndex makes sure the counter for the address type is past index, so
+// the address there is never generated again.
+func (w *SCWallet) skipIndex(root []byte, addrtype string, index uint32, key []byte) error {
+	counters, err := w.getCounters(root, key)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	if iroot == nil {
-		randomstuff := make([]byte, 1024)
-		rand.Read(randomstuff)
-		w.NewSeed(randomstuff)
+	offset := counterOffset(addrtype)
+	if binary.BigEndian.Uint32(counters[offset:]) > index {
+		return nil
 	}
-	hasher := sha512.New()
-	hasher.Write([]byte(w.NextSeed))
-	seedhash := hasher.Sum(nil)
-	w.NextSeed = seedhash
+	binary.BigEndian.PutUint32(counters[offset:], index+1)
+	return w.putSeed([]byte(constants.W_SEED_HEADS), root[:32], counters, key)
+}
 
-	b := new(bytestore.ByteStore)
-	b.SetBytes(w.NextSeed)
-	err = w.db.Put([]byte(constants.W_SEED_HEADS), w.RootSeed[:32], b)
+// getCounters returns the next index of each address type.  Wallets from
+// before HD derivation kept the 64 byte sha512 seed chain head under the
+// same key; it is taken as zeroed counters and overwritten by the next
+// address.  The addresses generated from that chain still work, as their
+// keys are in the wallet, but can't be recovered from the root seed or a
+// mnemonic, so such a wallet must be backed up as a file to keep them.
+func (w *SCWallet) getCounters(root []byte, key []byte) ([]byte, error) {
+	heads, err := w.getSeed([]byte(constants.W_SEED_HEADS), root[:32], key)
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+	counters := make([]byte, 8)
+	if len(heads) == len(counters) {
+		copy(counters, heads)
+	}
+	return counters, nil
+}
+
+func counterOffset(addrtype string) int {
+	if addrtype == "ec" {
+		return 4
 	}
-	return w.NextSeed
+	return 0
 }
 
-// This function pulls the next private key from the deterministic
-// private key generator, gets the public key associated with it
-// then prepares the generator for the next time a private key is needed.
-// To prepare the next state, it sha512s the previous sha512 output.
-// It returns a 32 byte public key, a 64 byte private key, and an error condition.
-// The private key is the SUPERCOP style with the private key in the first 32 bytes
-// and the public key is the last 32 bytes.
-// The public key essentially returns twice because of this.
-func (w *SCWallet) generateKey() (public []byte, private []byte, err error) {
+// DeriveKey returns the key pair at the given index for the address type,
+// derived from the root seed.  The private key is the SUPERCOP style with the
+// private key in the first 32 bytes and the public key in the last 32 bytes.
+func (w *SCWallet) DeriveKey(addrtype string, index uint32) (public []byte, private []byte, path []uint32, err error) {
+	w.writeMutex.Lock()
+	defer w.writeMutex.Unlock()
+	key, err := w.walletKey()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	root, err := w.rootSeed(key)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return deriveKeyPair(root, addrtype, index)
+}
 
+func deriveKeyPair(root []byte, addrtype string, index uint32) (public []byte, private []byte, path []uint32, err error) {
+	path, err = AddressPath(addrtype, index)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	secret, err := derivePath(root, path)
+	if err != nil {
+		return nil, nil, nil, err
+	}
 	keypair := new([64]byte)
-	// the secret part of the keypair is the top 32 bytes of the sha512 hash
-	copy(keypair[:32], w.GetSeed()[:32])
+	copy(keypair[:32], secret)
 	// the crypto library puts the pubkey in the lower 32 bytes and returns the same 32 bytes.
 	pub := ed25519.GetPublicKey(keypair)
 
-	return pub[:], keypair[:], err
+	return pub[:], keypair[:], path, nil
+}
+
+// generateKey derives the next key pair of the given address type from the
+// root seed.  It returns a 32 byte public key, a 64 byte private key, and the
+// derivation path of the pair.
+func (w *SCWallet) generateKey(addrtype string) (public []byte, private []byte, path []uint32, err error) {
+	key, err := w.walletKey()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	root, err := w.rootSeed(key)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	index, err := w.nextIndex(root, addrtype, key)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return deriveKeyPair(root, addrtype, index)
 }
 
 func (w *SCWallet) generateKeyFromPrivateKey(privateKey []byte) (public []byte, private []byte, err error) {
@@ -379,6 +929,9 @@ func (w *SCWallet) AddInput(trans interfaces.ITransaction, address interfaces.IA
 		}
 		trans.AddInput(CreateAddress(adr), amount)
 	} else {
+		if we.GetRCD() == nil {
+			return errNoRCD
+		}
 		trans.AddRCD(we.GetRCD())
 		trans.AddInput(CreateAddress(adr), amount)
 	}
@@ -398,6 +951,9 @@ func (w *SCWallet) UpdateInput(trans interfaces.ITransaction, index int, address
 		return err
 	}
 
+	if we.GetRCD() == nil {
+		return errNoRCD
+	}
 	trans.GetRCDs()[index] = we.GetRCD() // The RCD must match the (possibly) new input
 
 	in.SetAddress(adr)
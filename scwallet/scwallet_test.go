@@ -24,10 +24,9 @@ var _ = binary.Write
 var _ = primitives.Prtln
 
 func TestGenerateKeyFromPrivateKey(t *testing.T) {
-	w := new(SCWallet) // make me a wallet
-	w.Init()
-	w.NewSeed([]byte("lkdfsgjlagkjlasd"))
-	pub, priv, err := w.generateKey()
+	w, cleanup := newTestWallet(t)
+	defer cleanup()
+	pub, priv, _, err := w.generateKey("fct")
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -45,13 +44,12 @@ func TestGenerateKeyFromPrivateKey(t *testing.T) {
 }
 
 func Test_create_scwallet(test *testing.T) {
-	w := new(SCWallet) // make me a wallet
-	w.Init()
-	w.NewSeed([]byte("lkdfsgjlagkjlasd"))
+	w, cleanup := newTestWallet(test)
+	defer cleanup()
 	we := new(WalletEntry)
 	rcd := new(RCD_1)
 	name := "John Smith"
-	pub, pri, err := w.generateKey()
+	pub, pri, _, err := w.generateKey("fct")
 
 	if err != nil {
 		primitives.Prtln("Generate Failed")
@@ -69,9 +67,8 @@ func Test_create_scwallet(test *testing.T) {
 }
 
 func Test_GenerateAddress_scwallet(test *testing.T) {
-	w := new(SCWallet) // make me a wallet
-	w.Init()
-	w.NewSeed([]byte("lkdfsgjlagkjlasd"))
+	w, cleanup := newTestWallet(test)
+	defer cleanup()
 	h1, err := w.GenerateFctAddress([]byte("test 1"), 1, 1)
 	if err != nil {
 		test.Fail()
@@ -93,9 +90,8 @@ func Test_GenerateAddress_scwallet(test *testing.T) {
 }
 
 func Test_CreateTransaction_swcallet(test *testing.T) {
-	w := new(SCWallet) // make me a wallet
-	w.Init()
-	w.NewSeed([]byte("lkdfsgjlagkjlasd"))
+	w, cleanup := newTestWallet(test)
+	defer cleanup()
 	h1, err := w.GenerateFctAddress([]byte("test 1"), 1, 1)
 	if err != nil {
 		test.Fail()
@@ -131,9 +127,8 @@ func Test_CreateTransaction_swcallet(test *testing.T) {
 }
 
 func Test_SignTransaction_swcallet(test *testing.T) {
-	w := new(SCWallet) // make me a wallet
-	w.Init()
-	w.NewSeed([]byte("lkdfsgjlagkjlasd"))
+	w, cleanup := newTestWallet(test)
+	defer cleanup()
 	h0, err := w.GenerateFctAddress([]byte("test 0"), 1, 1)
 	if err != nil {
 		test.Fail()
@@ -30,8 +30,34 @@ type WalletEntry struct {
 	public [][]byte // Set of public keys necessary towe sign the rcd
 	// 1 byte count of private keys
 	private [][]byte // Set of private keys necessary to sign the rcd
+	// Private keys encrypted under the wallet key.  When set, this is what
+	// gets written to the database rather than the private keys.
+	sealed []byte
+	// Derivation path of the keys from the wallet root seed.  Empty for
+	// imported keys.
+	path []uint32
+	// Address of a watch-only Factoid address imported without its public
+	// key.  Such an entry has no rcd.
+	address []byte
+	// Set for addresses the wallet made to take the change of its own
+	// transactions.
+	change bool
 }
 
+// Marks a private key section that is encrypted rather than in the clear.
+// A plain section starts with the count of private keys, which is never
+// this large.
+const sealedMarker byte = 0xFF
+
+// Takes the place of the rcd for an entry that only has an address.  RCDs
+// start with their type, which is never 0.
+const addressOnlyMarker byte = 0x00
+
+// Bits of the flags byte that follows the derivation path
+const (
+	changeFlag byte = 1 << iota
+)
+
 var _ interfaces.IWalletEntry = (*WalletEntry)(nil)
 var _ interfaces.BinaryMarshallableAndCopyable = (*WalletEntry)(nil)
 
@@ -79,6 +105,9 @@ func (b WalletEntry) String() string {
 }
 
 func (w1 WalletEntry) GetAddress() (interfaces.IAddress, error) {
+	if w1.rcd == nil && w1.address != nil {
+		return primitives.NewHash(w1.address), nil
+	}
 	if w1.rcd == nil {
 		return nil, fmt.Errorf("Should never happen. Missing the rcd block")
 	}
@@ -134,12 +163,20 @@ func (w *WalletEntry) UnmarshalBinaryData(data []byte) ([]byte, error) {
 	data = data[siz:]           // update data pointer
 	w.name = n                  // Finally!  set the name
 
-	if w.rcd == nil {
-		w.rcd = CreateRCD(data) // looks ahead, and creates the right RCD
-	}
-	data, err := w.rcd.UnmarshalBinaryData(data)
-	if err != nil {
-		return nil, err
+	var err error
+	if data[0] == addressOnlyMarker {
+		w.rcd = nil
+		w.address = make([]byte, constants.ADDRESS_LENGTH, constants.ADDRESS_LENGTH)
+		copy(w.address, data[1:1+constants.ADDRESS_LENGTH])
+		data = data[1+constants.ADDRESS_LENGTH:]
+	} else {
+		if w.rcd == nil {
+			w.rcd = CreateRCD(data) // looks ahead, and creates the right RCD
+		}
+		data, err = w.rcd.UnmarshalBinaryData(data)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	blen, data := data[0], data[1:]
@@ -151,13 +188,50 @@ func (w *WalletEntry) UnmarshalBinaryData(data []byte) ([]byte, error) {
 	}
 
 	blen, data = data[0], data[1:]
-	w.private = make([][]byte, blen, blen)
+	if blen == sealedMarker {
+		if len(data) < 2 {
+			return nil, fmt.Errorf("Wallet entry is truncated in its sealed keys")
+		}
+		var siz uint16
+		siz, data = binary.BigEndian.Uint16(data[0:2]), data[2:]
+		if len(data) < int(siz) {
+			return nil, fmt.Errorf("Wallet entry has %d bytes of sealed keys, expected %d", len(data), siz)
+		}
+		w.sealed = make([]byte, siz, siz)
+		copy(w.sealed, data[:siz])
+		data = data[siz:]
+		w.private = nil
+	} else {
+		w.private = make([][]byte, blen, blen)
+		for i := 0; i < int(blen); i++ {
+			if bytes.Equal(data[:constants.PRIVATE_LENGTH], make([]byte, constants.PRIVATE_LENGTH)) {
+				data = data[constants.PRIVATE_LENGTH:]
+				continue // A multisig key held by someone else
+			}
+			w.private[i] = make([]byte, constants.PRIVATE_LENGTH, constants.PRIVATE_LENGTH)
+			copy(w.private[i], data[:constants.PRIVATE_LENGTH])
+			data = data[constants.PRIVATE_LENGTH:]
+		}
+	}
+
+	// Older entries end here, without a derivation path
+	w.path = nil
+	if len(data) == 0 {
+		return data, nil
+	}
+	blen, data = data[0], data[1:]
+	// The path is followed by the flags
+	if len(data) < 4*int(blen)+1 {
+		return nil, fmt.Errorf("Wallet entry is truncated in its derivation path or flags")
+	}
+	w.path = make([]uint32, blen, blen)
 	for i := 0; i < int(blen); i++ {
-		w.private[i] = make([]byte, constants.ADDRESS_LENGTH, constants.ADDRESS_LENGTH)
-		copy(w.private[i], data[:constants.ADDRESS_LENGTH])
-		data = data[constants.ADDRESS_LENGTH:]
+		w.path[i], data = binary.BigEndian.Uint32(data[0:4]), data[4:]
 	}
-	return data, nil
+
+	flags := data[0]
+	w.change = flags&changeFlag != 0
+	return data[1:], nil
 }
 
 func (w *WalletEntry) UnmarshalBinary(data []byte) error {
@@ -178,19 +252,46 @@ func (w WalletEntry) MarshalBinary() ([]byte, error) {
 
 	binary.Write(&out, binary.BigEndian, uint16(len([]byte(w.name))))
 	out.Write([]byte(w.name))
-	data, err := w.rcd.MarshalBinary()
-	if err != nil {
-		return nil, err
+	if w.rcd == nil {
+		if len(w.address) != constants.ADDRESS_LENGTH {
+			return nil, fmt.Errorf("Wallet entry has neither an rcd nor an address")
+		}
+		out.WriteByte(addressOnlyMarker)
+		out.Write(w.address)
+	} else {
+		data, err := w.rcd.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		out.Write(data)
 	}
-	out.Write(data)
 	out.WriteByte(byte(len(w.public)))
 	for _, public := range w.public {
 		out.Write(public)
 	}
-	out.WriteByte(byte(len(w.private)))
-	for _, private := range w.private {
-		out.Write(private)
+	if w.sealed != nil {
+		out.WriteByte(sealedMarker)
+		binary.Write(&out, binary.BigEndian, uint16(len(w.sealed)))
+		out.Write(w.sealed)
+	} else {
+		out.WriteByte(byte(len(w.private)))
+		for _, private := range w.private {
+			if len(private) == 0 {
+				// A multisig key held by someone else
+				private = make([]byte, constants.PRIVATE_LENGTH)
+			}
+			out.Write(private)
+		}
+	}
+	out.WriteByte(byte(len(w.path)))
+	for _, index := range w.path {
+		binary.Write(&out, binary.BigEndian, index)
 	}
+	var flags byte
+	if w.change {
+		flags |= changeFlag
+	}
+	out.WriteByte(flags)
 	return out.Bytes(), nil
 }
 
@@ -200,9 +301,24 @@ func (w WalletEntry) CustomMarshalText() (text []byte, err error) {
 	out.WriteString("name:  ")
 	out.Write(w.name)
 	out.WriteString("\n factoid address:")
-	hash, err := w.rcd.GetAddress()
+	hash, err := w.GetAddress()
+	if err != nil {
+		return nil, err
+	}
 	out.WriteString(hash.String())
 	out.WriteString("\n")
+	if w.IsWatchOnly() {
+		out.WriteString(" watch-only\n")
+	}
+	if w.change {
+		out.WriteString(" change\n")
+	}
+
+	if len(w.path) > 0 {
+		out.WriteString(" path: ")
+		out.WriteString(PathString(w.path))
+		out.WriteString("\n")
+	}
 
 	out.WriteString("\n public:  ")
 	for i, public := range w.public {
@@ -214,6 +330,9 @@ func (w WalletEntry) CustomMarshalText() (text []byte, err error) {
 	}
 
 	out.WriteString("\n private:  ")
+	if w.sealed != nil && w.private == nil {
+		out.WriteString("encrypted\n")
+	}
 	for i, private := range w.private {
 		primitives.WriteNumber16(&out, uint16(i))
 		out.WriteString(" ")
@@ -250,6 +369,26 @@ func (w *WalletEntry) AddKey(public, private []byte) {
 	w.rcd = NewRCD_1(pu)
 }
 
+// AddPublicKey adds a key this wallet can't sign with, such as another
+// participant's key in a multisig address.
+func (w *WalletEntry) AddPublicKey(public []byte) {
+	if len(public) != constants.ADDRESS_LENGTH {
+		panic(fmt.Sprintf("Bad Key presented to AddPublicKey.  Should not happen."+
+			"\n  public: %x", public))
+	}
+	pu := make([]byte, constants.ADDRESS_LENGTH, constants.ADDRESS_LENGTH)
+	copy(pu, public)
+	w.public = append(w.public, pu)
+	w.private = append(w.private, nil)
+}
+
+// IsSingleKey is true for an ordinary address, with an RCD_1 over a single
+// public key.
+func (w *WalletEntry) IsSingleKey() bool {
+	_, ok := w.rcd.(*RCD_1)
+	return ok && len(w.public) == 1
+}
+
 func (we *WalletEntry) GetKey(i int) []byte {
 	return we.public[i]
 }
@@ -258,6 +397,90 @@ func (we *WalletEntry) GetPrivKey(i int) []byte {
 	return we.private[i]
 }
 
+// Seal encrypts the private keys of the entry under the wallet key.  The
+// public keys are authenticated along with them, so a sealed section cannot
+// be moved onto another entry.
+func (w *WalletEntry) Seal(key []byte) error {
+	sealed, err := seal(key, marshalPrivate(w.private), w.publicData())
+	if err != nil {
+		return err
+	}
+	w.sealed = sealed
+	return nil
+}
+
+// Unseal decrypts the private keys of a sealed entry.  Entries that are not
+// sealed are left alone.
+func (w *WalletEntry) Unseal(key []byte) error {
+	if w.sealed == nil {
+		return nil
+	}
+	data, err := open(key, w.sealed, w.publicData())
+	if err != nil {
+		return err
+	}
+	private, err := unmarshalPrivate(data)
+	if err != nil {
+		return err
+	}
+	w.private = private
+	return nil
+}
+
+func (w *WalletEntry) IsSealed() bool {
+	return w.sealed != nil
+}
+
+func (w *WalletEntry) publicData() []byte {
+	var out bytes.Buffer
+	for _, public := range w.public {
+		out.Write(public)
+	}
+	return out.Bytes()
+}
+
+func marshalPrivate(private [][]byte) []byte {
+	var out bytes.Buffer
+	out.WriteByte(byte(len(private)))
+	for _, p := range private {
+		out.WriteByte(byte(len(p)))
+		out.Write(p)
+	}
+	return out.Bytes()
+}
+
+func unmarshalPrivate(data []byte) ([][]byte, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("Missing private key count")
+	}
+	blen, data := data[0], data[1:]
+	private := make([][]byte, blen, blen)
+	for i := 0; i < int(blen); i++ {
+		if len(data) == 0 || len(data) < 1+int(data[0]) {
+			return nil, fmt.Errorf("Private key section is truncated")
+		}
+		klen := int(data[0])
+		private[i] = make([]byte, klen, klen)
+		copy(private[i], data[1:1+klen])
+		data = data[1+klen:]
+	}
+	return private, nil
+}
+
+func (w *WalletEntry) SetPath(path []uint32) {
+	if len(path) == 0 {
+		w.path = nil
+		return
+	}
+	w.path = append([]uint32{}, path...)
+}
+
+// GetPath returns the derivation path of the keys from the wallet root
+// seed, or nil if the keys were imported.
+func (w *WalletEntry) GetPath() []uint32 {
+	return w.path
+}
+
 func (w *WalletEntry) SetName(name []byte) {
 	w.name = name
 }
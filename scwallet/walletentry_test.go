@@ -5,6 +5,8 @@
 package scwallet
 
 import (
+	"bytes"
+	crand "crypto/rand"
 	"encoding/binary"
 	"fmt"
 	"github.com/FactomProject/ed25519"
@@ -20,14 +22,13 @@ var _ = rand.New
 var _ = binary.Write
 
 func Test_create_walletentry(test *testing.T) {
-	w := new(SCWallet) // make me a wallet
-	w.Init()
-	w.NewSeed([]byte("lkdfsgjlagkjlasd"))
+	w, cleanup := newTestWallet(test)
+	defer cleanup()
 	we := new(WalletEntry)
 	rcd := new(RCD_1)
 	name := "John Smith"
 	adrtype := "fct"
-	pub, pri, err := w.generateKey()
+	pub, pri, _, err := w.generateKey("fct")
 
 	if err != nil {
 		primitives.Prtln("Generate Failed")
@@ -55,3 +56,60 @@ func Test_create_walletentry(test *testing.T) {
 		test.Fail()
 	}
 }
+
+func Test_seal_walletentry(test *testing.T) {
+	pub, pri, err := ed25519.GenerateKey(crand.Reader)
+	if err != nil {
+		test.Fatal(err)
+	}
+	we := new(WalletEntry)
+	we.AddKey(pub[:], pri[:])
+	we.SetName([]byte("sealed"))
+	we.SetType("fct")
+
+	key, err := deriveKey([]byte("correct horse"), make([]byte, cryptoSaltLength))
+	if err != nil {
+		test.Fatal(err)
+	}
+	if err := we.Seal(key); err != nil {
+		test.Fatal(err)
+	}
+
+	data, err := we.MarshalBinary()
+	if err != nil {
+		test.Fatal(err)
+	}
+	if bytes.Contains(data, pri[:32]) {
+		test.Error("Private key written in the clear")
+	}
+
+	// The sealed keys are followed by the path and flags.
+	if err := new(WalletEntry).UnmarshalBinary(data[:len(data)-10]); err == nil {
+		test.Error("Read a truncated entry")
+	}
+	if err := new(WalletEntry).UnmarshalBinary(data[:len(data)-1]); err == nil {
+		test.Error("Read an entry without its flags")
+	}
+
+	w2 := new(WalletEntry)
+	if err := w2.UnmarshalBinary(data); err != nil {
+		test.Fatal(err)
+	}
+	if !w2.IsSealed() || len(w2.private) != 0 {
+		test.Error("Private keys should stay sealed until opened")
+	}
+	if we.IsEqual(w2) != nil {
+		test.Error("Public data does not match")
+	}
+
+	wrong, _ := deriveKey([]byte("wrong horse"), make([]byte, cryptoSaltLength))
+	if err := w2.Unseal(wrong); err == nil {
+		test.Error("Unsealed with the wrong key")
+	}
+	if err := w2.Unseal(key); err != nil {
+		test.Fatal(err)
+	}
+	if bytes.Compare(w2.GetPrivKey(0), we.GetPrivKey(0)) != 0 {
+		test.Error("Private keys are not identical")
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package scwallet
+
+import (
+	"fmt"
+
+	"github.com/FactomProject/factomd/common/constants"
+	. "github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/common/interfaces"
+)
+
+// Watch-only entries let the wallet follow balances and transactions of
+// addresses whose keys are kept elsewhere, such as in cold storage.  They
+// are imported either by public key, or for Factoid addresses by the
+// address alone.  With just the address the wallet can't build the RCD, so
+// it can't use the address as an input either.
+
+var ErrWatchOnly = fmt.Errorf("Cannot sign with a watch-only address")
+
+var errNoRCD = fmt.Errorf("The public key of this watch-only address is unknown. Import the address by its public key to spend from it")
+
+// IsWatchOnly is true for an entry with no private keys.
+func (w *WalletEntry) IsWatchOnly() bool {
+	return w.sealed == nil && !w.hasPrivateKeys()
+}
+
+func (w *WalletEntry) hasPrivateKeys() bool {
+	for _, private := range w.private {
+		if len(private) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// AddWatchOnlyKey adds an address for which only the public key is known.
+func (w *SCWallet) AddWatchOnlyKey(addrtype string, name []byte, pub []byte) (interfaces.IAddress, error) {
+	if len(pub) != constants.ADDRESS_LENGTH {
+		return nil, fmt.Errorf("Invalid public key %x", pub)
+	}
+	p, err := w.db.FetchWalletEntryByPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	if p != nil {
+		return nil, fmt.Errorf("Address already exists in the wallet")
+	}
+
+	we := new(WalletEntry)
+	we.AddPublicKey(pub)
+	we.SetRCD(NewRCD_1(pub))
+	return w.addWatchOnly(addrtype, name, we)
+}
+
+// AddWatchOnlyAddress adds a Factoid address for which only the address
+// itself is known.
+func (w *SCWallet) AddWatchOnlyAddress(name []byte, address []byte) (interfaces.IAddress, error) {
+	if len(address) != constants.ADDRESS_LENGTH {
+		return nil, fmt.Errorf("Invalid address %x", address)
+	}
+	we := new(WalletEntry)
+	we.address = make([]byte, constants.ADDRESS_LENGTH)
+	copy(we.address, address)
+	return w.addWatchOnly("fct", name, we)
+}
+
+func (w *SCWallet) addWatchOnly(addrtype string, name []byte, we *WalletEntry) (interfaces.IAddress, error) {
+	nm, err := w.db.FetchWalletEntryByName(name)
+	if err != nil {
+		return nil, err
+	}
+	if nm != nil {
+		return nil, fmt.Errorf("The name '%s' already exists. Duplicate names are not supported", string(name))
+	}
+
+	we.SetName(name)
+	we.SetType(addrtype)
+	address, err := we.GetAddress()
+	if err != nil {
+		return nil, err
+	}
+	existing, err := w.db.Get([]byte(constants.W_RCD_ADDRESS_HASH), address.Bytes(), new(WalletEntry))
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("Address already exists in the wallet")
+	}
+
+	// Nothing to encrypt, so this works on a locked wallet
+	if err := w.saveWalletEntry(we, nil); err != nil {
+		return nil, err
+	}
+	return address, nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2015 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package scwallet
+
+import (
+	"testing"
+)
+
+func TestWatchOnlyAddresses(t *testing.T) {
+	cold, cleanup1 := newTestWallet(t)
+	defer cleanup1()
+	w, cleanup2 := newTestWallet(t)
+	defer cleanup2()
+
+	publics := sharedPublicKeys(t, cold)
+	adr, err := w.AddWatchOnlyKey("fct", []byte("cold"), publics[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.EncryptWallet([]byte("passphrase")); err != nil {
+		t.Fatal(err)
+	}
+
+	we, err := w.GetDB().FetchWalletEntryByName([]byte("cold"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !we.(*WalletEntry).IsWatchOnly() {
+		t.Error("Expected a watch-only entry")
+	}
+	if _, err := w.GetPrivateKey(we, 0); err != ErrWatchOnly {
+		t.Errorf("Expected %v, got %v", ErrWatchOnly, err)
+	}
+
+	trans := w.CreateTransaction(0)
+	if err := w.AddInput(trans, adr, 1000000); err != nil {
+		t.Fatal(err)
+	}
+	w.AddOutput(trans, adr, 1000000-12000)
+	if signed, err := w.SignInputs(trans); signed || err != nil {
+		t.Errorf("Signed a watch-only input: %v %v", signed, err)
+	}
+	if signed, err := cold.SignInputs(trans); !signed || err != nil {
+		t.Errorf("The wallet with the key should sign: %v %v", signed, err)
+	}
+
+	// By address alone, the address can be followed but not spent from
+	if _, err := w.AddWatchOnlyAddress([]byte("by address"), adr.Bytes()); err == nil {
+		t.Error("Added the same address twice")
+	}
+	w2, cleanup3 := newTestWallet(t)
+	defer cleanup3()
+	byAddress, err := w2.AddWatchOnlyAddress([]byte("by address"), adr.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if byAddress.IsEqual(adr) != nil {
+		t.Error("Watch-only address differs from the original")
+	}
+	if err := w2.AddInput(w2.CreateTransaction(0), byAddress, 1000); err != errNoRCD {
+		t.Errorf("Expected %v, got %v", errNoRCD, err)
+	}
+
+	we, err = w2.GetDB().FetchWalletEntryByName([]byte("by address"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := we.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	we2 := new(WalletEntry)
+	if err := we2.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if a, err := we2.GetAddress(); err != nil || a.IsEqual(adr) != nil {
+		t.Errorf("Address lost in marshalling: %v", err)
+	}
+}
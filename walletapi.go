@@ -5,10 +5,14 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"github.com/FactomProject/web"
+	"os"
+	"strings"
 	"time"
 
+	"github.com/FactomProject/fctwallet2/Wallet"
 	"github.com/FactomProject/fctwallet2/handlers"
 )
 
@@ -47,6 +51,24 @@ func Start() {
 
 	server.Get("/v1/factoid-generate-address-from-token-sale/(.*)", handlers.HandleFactoidGenerateAddressFromMnemonic)
 
+	// Generate Multisig Address
+	// localhost:8089/v1/factoid-generate-multisig-address/?name=<name>&m=<count>&keys=<name or public key>,...
+	// Tie an address that needs m signatures from the given keys to the name.
+	// Keys are names of Factoid addresses in this wallet, or the public keys
+	// of other participants in hex.  Signing fills in what this wallet can.
+	// factomd doesn't check multisig signatures yet, so funds sent to the
+	// address can't be spent until it does.  The V2 response carries this
+	// as a warning.
+	server.Post("/v1/factoid-generate-multisig-address/(.*)", handlers.HandleFactoidGenerateMultisigAddress)
+
+	// Import Watch-only Address
+	// localhost:8089/v1/import-watch-only-address/?name=<name>&address=<address or public key>&type=<fct or ec>
+	// Tie an address the wallet holds no keys for to the name, to follow its
+	// balance and transactions.  Give an FA or EC address, or a public key in
+	// hex.  Addresses imported by public key can be used as inputs, but the
+	// transaction has to be signed by the wallet with the key.
+	server.Post("/v1/import-watch-only-address/(.*)", handlers.HandleImportWatchOnlyAddress)
+
 	// verify-address-type
 	// localhost:8089/v1/verify-address-type/address=<address>
 	// take address and define its type or fail if not valid address
@@ -84,7 +106,10 @@ func Start() {
 	// localhost:8089/v1/factoid-add-input/?key=<key>&name=<name or address>
 	// Add the fee for this transaction to the input specified by the name or address.
 	// If the name or address is not an input to this transaction, then an error
-	// is posted.
+	// is posted.  Give &rate=<fee rate> to set the fee rate rather than ask
+	// factomd, as an offline wallet must.  If the inputs are more than the
+	// outputs, the fee comes out of the difference and the rest goes to the
+	// change address.
 	server.Post("/v1/factoid-add-fee/(.*)", handlers.HandleFactoidAddFee)
 
 	// Add Input
@@ -110,6 +135,34 @@ func Start() {
 	// can cover the transaction.  Use validate to do that.
 	server.Post("/v1/factoid-sign-transaction/(.*)", handlers.HandleFactoidSignTransaction)
 
+	// Export Transaction
+	// localhost:8089/v1/factoid-export-transaction/<key>
+	// Returns the transaction as a partially signed transaction: the
+	// transaction with the RCD for each input and the signatures collected so
+	// far, in hex.  Pass it to the other signers of a multisig input.
+	server.Post("/v1/factoid-export-transaction/(.*)", handlers.HandleFactoidExportTransaction)
+
+	// Import Transaction
+	// localhost:8089/v1/factoid-import-transaction/?key=<key>&transaction=<hex>
+	// Save a partially signed transaction from another wallet under the key.
+	server.Post("/v1/factoid-import-transaction/(.*)", handlers.HandleFactoidImportTransaction)
+
+	// Cosign Transaction
+	// localhost:8089/v1/factoid-cosign-transaction/<key>
+	// Add every signature this wallet can to the transaction, even if other
+	// signatures are still needed.  The response says if it is fully signed.
+	// factomd doesn't check multisig signatures yet, so a transaction with a
+	// multisig input can't be submitted however many are collected.
+	server.Post("/v1/factoid-cosign-transaction/(.*)", handlers.HandleFactoidCosignTransaction)
+
+	// Merge Transaction
+	// localhost:8089/v1/factoid-merge-transaction/?key=<key>&transaction=<hex>
+	// Add the signatures from a partially signed copy of the transaction under
+	// the key.  The response says if it is fully signed.  A transaction with
+	// a multisig input can't be submitted until factomd checks multisig
+	// signatures.
+	server.Post("/v1/factoid-merge-transaction/(.*)", handlers.HandleFactoidMergeTransaction)
+
 	// Setup
 	// localhost:8089/v1/factoid-setup/<key>
 	// hashes the given data to create a new seed from which to generate addresses.
@@ -118,6 +171,58 @@ func Start() {
 	// removed the API to create a new seed in a wallet.
 	// server.Post("/v1/factoid-setup/(.*)", handlers.HandleFactoidSetup)
 
+	// Encrypt Wallet
+	// localhost:8089/v1/wallet-encrypt/
+	// Posted: passphrase=<passphrase>
+	// Set the passphrase of a wallet that doesn't have one.  Every private key
+	// and seed in the wallet is encrypted under it from then on.
+	server.Post("/v1/wallet-encrypt/(.*)", handlers.HandleWalletEncrypt)
+
+	// Unlock Wallet
+	// localhost:8089/v1/wallet-unlock/?timeout=<seconds>
+	// Posted: passphrase=<passphrase>
+	// Make the keys of an encrypted wallet usable for signing transactions and
+	// commits.  The wallet locks itself again after timeout seconds.
+	server.Post("/v1/wallet-unlock/(.*)", handlers.HandleWalletUnlock)
+
+	// Lock Wallet
+	// localhost:8089/v1/wallet-lock/
+	// Lock the wallet right away.  Signing fails until it is unlocked again.
+	server.Post("/v1/wallet-lock/(.*)", handlers.HandleWalletLock)
+
+	// Change Passphrase
+	// localhost:8089/v1/wallet-change-passphrase/
+	// Posted: old=<passphrase>&new=<passphrase>
+	// Re-encrypt every key and seed in the wallet under a new passphrase.
+	server.Post("/v1/wallet-change-passphrase/(.*)", handlers.HandleWalletChangePassphrase)
+
+	// Export Mnemonic
+	// localhost:8089/v1/wallet-export-mnemonic/
+	// Returns the BIP39 mnemonic that backs up the wallet root seed.  Every
+	// generated address can be recovered from it.
+	server.Post("/v1/wallet-export-mnemonic/(.*)", handlers.HandleWalletExportMnemonic)
+
+	// New Mnemonic
+	// localhost:8089/v1/wallet-new-mnemonic/?words=<12 or 24>
+	// Give a wallet with no addresses a fresh root seed, and return its
+	// mnemonic.
+	server.Post("/v1/wallet-new-mnemonic/(.*)", handlers.HandleWalletNewMnemonic)
+
+	// Import Mnemonic
+	// localhost:8089/v1/wallet-import-mnemonic/?fct=<count>&ec=<count>&gap=<count>
+	// Posted: mnemonic=<words>
+	// Initialize a wallet with no addresses from a backup mnemonic, and
+	// recreate the given number of Factoid and Entry Credit addresses.  With
+	// a gap, used addresses past those are found and imported as well.
+	server.Post("/v1/wallet-import-mnemonic/(.*)", handlers.HandleWalletImportMnemonic)
+
+	// Discover Addresses
+	// localhost:8089/v1/wallet-discover-addresses/?gap=<count>
+	// Derive addresses from the root seed until gap addresses in a row have
+	// no balance and no transactions.  Used addresses the wallet doesn't
+	// have are imported under generated names.
+	server.Post("/v1/wallet-discover-addresses/(.*)", handlers.HandleWalletDiscoverAddresses)
+
 	// Commit Chain
 	// localhost:8089/v1/commit-chain/
 	// sign a binary Chain Commit with an entry credit key and submit it to the
@@ -135,6 +240,51 @@ func Start() {
 	// Put the key for the transaction in {Transaction string}
 	server.Post("/v1/factoid-submit/(.*)", handlers.HandleFactoidSubmit)
 
+	// Send
+	// localhost:8089/v1/factoid-send/?to=<name or address>,...&amount=<amount>,...
+	// Pay each address its amount in one transaction.  The wallet picks the
+	// inputs from its own addresses, adds the fee and a change output, then
+	// signs and submits.  Amounts are in factoshis.
+	server.Post("/v1/factoid-send/(.*)", handlers.HandleFactoidSend)
+
+	// Sweep
+	// localhost:8089/v1/factoid-sweep/?from=<name, address or Fs key>&to=<name or address>
+	// Move the whole balance of an address, less the fee, to another address.
+	// The source can be one of the wallet's addresses, or a private key such
+	// as a paper wallet, which is used to sign and then forgotten.
+	server.Post("/v1/factoid-sweep/(.*)", handlers.HandleFactoidSweep)
+
+	// Batch Pay
+	// localhost:8089/v1/factoid-batch-pay/?from=<name or address>&dryrun=<true or false>
+	// Pay a list of FA or EC addresses from one address.  The rows are posted
+	// as CSV lines of address,amount or a JSON list, amounts in factoshis.
+	// Large batches are split over several transactions.  Returns each row
+	// with its transaction id, and the total and fees.  A dry run just
+	// reports the cost.  Each address can only be paid once in a batch.
+	server.Post("/v1/factoid-batch-pay/(.*)", handlers.HandleFactoidBatchPay)
+
+	// Buy Entry Credits
+	// localhost:8089/v1/factoid-buy-ec/?from=<name or address>&to=<name or EC address>&credits=<count>&wait=<true or false>
+	// Buy the number of entry credits at the rate factomd has now, paid for
+	// from the given address, and return the entry credit balance.  With
+	// wait=true, waits up to two minutes for the entry credits to show up
+	// first.
+	server.Post("/v1/factoid-buy-ec/(.*)", handlers.HandleFactoidBuyEC)
+
+	// Set Change Address
+	// localhost:8089/v1/set-change-address/?name=<name>
+	// Send the change of transactions the wallet funds, with send or add-fee,
+	// to the named address.  With no name, a fresh change address is
+	// generated each time.  Those are listed apart from other addresses.
+	server.Post("/v1/set-change-address/(.*)", handlers.HandleSetChangeAddress)
+
+	// Submit Signed Transaction
+	// localhost:8089/v1/factoid-submit-transaction/?transaction=<hex>
+	// Submit a signed transaction exported from another wallet, such as an
+	// offline wallet that holds the keys.  Transactions with a multisig input
+	// are refused until factomd checks multisig signatures.
+	server.Post("/v1/factoid-submit-transaction/(.*)", handlers.HandleFactoidSubmitTransaction)
+
 	// Validate
 	// localhost:8089/v1/factoid-validate/<key>
 	// Validates amounts and that all required signatures are applied, returns success = true
@@ -143,16 +293,21 @@ func Start() {
 
 	// Get Fee
 	// localhost:8089/v1/factoid-get-fee/
-	// Get the Transaction fee
+	// Get the Transaction fee.  With ?key=<key>&rate=<fee rate>, works out the
+	// fee of a transaction at the given rate without asking factomd.
 	server.Get("/v1/factoid-get-fee/(.*)", handlers.HandleGetFee)
 
-	// Get Fee
-	// localhost:8089/v1/factoid-get-fee/
-	// Get the Transaction fee
+	// Properties
+	// localhost:8089/v1/properties/
+	// Get the protocol, factomd and fctwallet versions, and the factomd node
+	// the wallet is using.
 	server.Get("/v1/properties/", handlers.HandleProperties)
 
 	// Get Address List
-	// localhost:8089/v1/factoid-get-addresses/
+	// localhost:8089/v1/factoid-get-addresses/?check=<true or false>
+	// Balances are computed from the blocks the wallet has synced.  With
+	// check, factomd is asked for each balance too, and its balance is shown
+	// next to any that differ.  A balance that couldn't be checked says why.
 	server.Get("/v1/factoid-get-addresses/", handlers.HandleGetAddresses)
 
 	// Get transactions
@@ -171,15 +326,43 @@ func Start() {
 	// localhost:8089/v1/factoid-get-addresses/
 	server.Post("/v1/factoid-get-processed-transactionsj/(.*)", handlers.HandleGetProcessedTransactionsj)
 
+	// Transaction history
+	// localhost:8089/v1/factoid-get-history/?addresses=<name or address>,...&directions=<in,out,ec,commit>
+	// Also fromheight, toheight, fromtime and totime (in milliseconds),
+	// minamount in factoshis, which commits are not filtered by, and after
+	// and limit to page through it.  Returns a page of
+	// the synced transactions and commits of the addresses, or of the whole
+	// wallet, newest first, as JSON.  Pass its Next cursor as after to get
+	// the page after it.
+	server.Get("/v1/factoid-get-history/(.*)", handlers.HandleGetHistory)
+
+	// JSON 2.0 API
+	// localhost:8089/v2
+	// Requests that change the wallet are POSTed, queries use GET.
+	server.Post("/v2", handlers.HandleV2Post)
+	server.Get("/v2", handlers.HandleV2Get)
+
 	go server.Run(fmt.Sprintf("%s:%d", handlers.IpAddress, handlers.PortNumber))
 }
 
 func main() {
+	offline := flag.Bool("offline", false, "Hold keys and sign only; never contact factomd")
+	api := flag.String("factomd-api", Wallet.APIv1, "Version of the factomd API to use, v1 or v2")
+	nodes := flag.String("factomd", Wallet.DefaultEndpoint(), "Comma separated list of factomd nodes as host:port")
+	flag.Parse()
 
 	fmt.Println("+================+")
 	fmt.Println("|  fctwallet v1  |")
 	fmt.Println("+================+")
 
+	if *offline {
+		fmt.Println("Offline mode: factomd will not be contacted")
+		Wallet.SetOffline(true)
+	} else if err := Wallet.Connect(*api, strings.Split(*nodes, ",")); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
 	Start()
 	for {
 		time.Sleep(time.Second)